@@ -0,0 +1,109 @@
+// Package airgradient is a client for the AirGradient public API.
+package airgradient
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Measures are the current measures of a single location. Sensor readings
+// are Values so a missing sensor is not mistaken for a zero reading.
+type Measures struct {
+	LocationID         int       `json:"locationId"`
+	LocationName       string    `json:"locationName"`
+	Pm01               Value     `json:"pm01"`
+	Pm02               Value     `json:"pm02"`
+	Pm10               Value     `json:"pm10"`
+	Pm003Count         Value     `json:"pm003Count"`
+	Atmp               Value     `json:"atmp"`
+	Rhum               Value     `json:"rhum"`
+	Rco2               Value     `json:"rco2"`
+	Tvoc               Value     `json:"tvoc"`
+	Wifi               Value     `json:"wifi"`
+	Timestamp          time.Time `json:"timestamp"`
+	LedMode            string    `json:"ledMode"`
+	LedCo2Threshold1   float64   `json:"ledCo2Threshold1"`
+	LedCo2Threshold2   float64   `json:"ledCo2Threshold2"`
+	LedCo2ThresholdEnd float64   `json:"ledCo2ThresholdEnd"`
+	Serialno           string    `json:"serialno"`
+	FirmwareVersion    string    `json:"firmwareVersion"`
+	Model              string    `json:"model"`
+	TvocIndex          Value     `json:"tvocIndex"`
+	NoxIndex           Value     `json:"noxIndex"`
+
+	// Pm02Correction names the correction applied to Pm02, CorrectionEPA or
+	// CorrectionDevice, and is empty for a raw reading. Pm02Raw then holds
+	// the reading before the correction.
+	Pm02Correction string `json:"-"`
+	Pm02Raw        Value  `json:"-"`
+}
+
+// currentURL returns the current measures URL of a location, or of every
+// location when locationID is 0.
+func (c *Client) currentURL(locationID int) string {
+	if locationID != 0 {
+		return fmt.Sprintf("%s/locations/%d/measures/current", c.baseURL, locationID)
+	}
+	return fmt.Sprintf("%s/locations/measures/current", c.baseURL)
+}
+
+// Current returns the current measures of the location, or of every location
+// of the token's place when locationID is 0, ordered by LocationID.
+func (c *Client) Current(ctx context.Context, locationID int) ([]Measures, error) {
+	payload, err := c.get(ctx, c.currentURL(locationID), nil)
+	if err != nil {
+		return nil, err
+	}
+	return ParseMeasures(payload)
+}
+
+// ParseMeasures decodes either a single location object or an array of
+// locations into a slice ordered by LocationID.
+func ParseMeasures(payload []byte) ([]Measures, error) {
+	// Try to unmarshal as a single object first
+	var measures Measures
+	if err := json.Unmarshal(payload, &measures); err == nil {
+		return []Measures{measures}, nil
+	}
+
+	// If that failed, try as an array
+	var arrayMeasures []Measures
+	if err := json.Unmarshal(payload, &arrayMeasures); err == nil && len(arrayMeasures) > 0 {
+		slices.SortStableFunc(arrayMeasures, func(a, b Measures) int {
+			return a.LocationID - b.LocationID
+		})
+		return arrayMeasures, nil
+	}
+
+	return nil, ErrBadPayload
+}
+
+// SelectLocations returns the measures matching any of the selectors. A
+// selector is either a location ID or a case-insensitive location name. An
+// empty selector list selects every location.
+func SelectLocations(measures []Measures, selectors []string) []Measures {
+	if len(selectors) == 0 {
+		return measures
+	}
+
+	var selected []Measures
+	for _, m := range measures {
+		for _, sel := range selectors {
+			sel = strings.TrimSpace(sel)
+			if id, err := strconv.Atoi(sel); err == nil && id == m.LocationID {
+				selected = append(selected, m)
+				break
+			}
+			if strings.EqualFold(sel, m.LocationName) {
+				selected = append(selected, m)
+				break
+			}
+		}
+	}
+	return selected
+}
@@ -0,0 +1,145 @@
+package airgradient
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCurrentURL(t *testing.T) {
+	testCases := []struct {
+		name        string
+		locationID  int
+		expectedURL string
+	}{
+		{
+			"location-id-0",
+			0,
+			"https://api.airgradient.com/public/api/v1/locations/measures/current",
+		},
+		{
+			"location-id-12345",
+			12345,
+			"https://api.airgradient.com/public/api/v1/locations/12345/measures/current",
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.name, func(t *testing.T) {
+			assert.Equal(t, tC.expectedURL, NewClient().currentURL(tC.locationID))
+		})
+	}
+}
+
+func TestCurrent(t *testing.T) {
+	testCases := []struct {
+		name        string
+		payloadFile string
+		err         error
+	}{
+		{
+			"correct-api-v1-locations-measures-current",
+			"testdata/api-v1-locations-measures-current.json",
+			nil,
+		},
+		{
+			"correct-api-v1-locations-measures-current-with-more-float64",
+			"testdata/api-v1-locations-measures-current-with-more-float64.json",
+			nil,
+		},
+		{
+			"correct-api-v1-locations-12345-measures-current",
+			"testdata/api-v1-locations-12345-measures-current.json",
+			nil,
+		},
+		{
+			"correct-api-v1-locations-measures-current-multiple",
+			"testdata/api-v1-locations-measures-current-multiple.json",
+			nil,
+		},
+		{
+			"incorrect-response-404",
+			"testdata/incorrect-response-404.json",
+			errors.New("error unmarshalling JSON"),
+		},
+	}
+
+	for _, tC := range testCases {
+		t.Run(tC.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				http.ServeFile(w, r, tC.payloadFile)
+			}))
+			defer server.Close()
+
+			client := NewClient(WithBaseURL(server.URL), WithToken("SECRET-TOKEN"))
+			_, err := client.Current(context.Background(), 0)
+			assert.Equal(t, tC.err, err)
+		})
+	}
+}
+
+func TestCurrentMultipleLocations(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.ServeFile(w, r, "testdata/api-v1-locations-measures-current-multiple.json")
+	}))
+	defer server.Close()
+
+	client := NewClient(WithBaseURL(server.URL), WithToken("SECRET-TOKEN"))
+	measures, err := client.Current(context.Background(), 0)
+	assert.NoError(t, err)
+	assert.Len(t, measures, 2)
+	assert.Equal(t, 12345, measures[0].LocationID)
+	assert.Equal(t, 23456, measures[1].LocationID)
+}
+
+func TestSelectLocations(t *testing.T) {
+	measures := []Measures{
+		{LocationID: 12345, LocationName: "Test Loc"},
+		{LocationID: 23456, LocationName: "Meeting Room"},
+		{LocationID: 34567, LocationName: "Kitchen"},
+	}
+
+	testCases := []struct {
+		name      string
+		selectors []string
+		expected  []int
+	}{
+		{
+			"no-selectors",
+			nil,
+			[]int{12345, 23456, 34567},
+		},
+		{
+			"by-id",
+			[]string{"23456"},
+			[]int{23456},
+		},
+		{
+			"by-name-case-insensitive",
+			[]string{"meeting room", "KITCHEN"},
+			[]int{23456, 34567},
+		},
+		{
+			"mixed",
+			[]string{"12345", "Kitchen"},
+			[]int{12345, 34567},
+		},
+		{
+			"unknown",
+			[]string{"Garage"},
+			nil,
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.name, func(t *testing.T) {
+			var ids []int
+			for _, m := range SelectLocations(measures, tC.selectors) {
+				ids = append(ids, m.LocationID)
+			}
+			assert.Equal(t, tC.expected, ids)
+		})
+	}
+}
@@ -0,0 +1,190 @@
+package airgradient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/ljagiello/airdash/internal/sleep"
+)
+
+const (
+	// DefaultBaseURL is the AirGradient public API v1 endpoint.
+	DefaultBaseURL = "https://api.airgradient.com/public/api/v1"
+	// DefaultTimeout bounds a single API request.
+	DefaultTimeout = 10 * time.Second
+	// DefaultUserAgent is sent when no other user agent is configured.
+	DefaultUserAgent = "airdash"
+)
+
+// Client talks to the AirGradient public API. The zero value is not usable,
+// create one with NewClient.
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+	token      string
+	userAgent  string
+	timeout    time.Duration
+	retry      RetryPolicy
+	sleep      func(ctx context.Context, d time.Duration) error
+}
+
+// Option configures a Client.
+type Option func(*Client)
+
+// WithBaseURL points the client at another API endpoint, e.g. a test server.
+func WithBaseURL(baseURL string) Option {
+	return func(c *Client) {
+		c.baseURL = baseURL
+	}
+}
+
+// WithHTTPClient sets the HTTP client used for requests.
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// WithToken sets the API token sent with every request.
+func WithToken(token string) Option {
+	return func(c *Client) {
+		c.token = token
+	}
+}
+
+// WithUserAgent sets the User-Agent header.
+func WithUserAgent(userAgent string) Option {
+	return func(c *Client) {
+		c.userAgent = userAgent
+	}
+}
+
+// WithTimeout bounds every request, overriding the timeout of a client set
+// with WithHTTPClient.
+func WithTimeout(timeout time.Duration) Option {
+	return func(c *Client) {
+		c.timeout = timeout
+	}
+}
+
+// WithRetryPolicy sets how transient failures are retried. Use
+// RetryPolicy{MaxAttempts: 1} to disable retries.
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return func(c *Client) {
+		c.retry = policy
+	}
+}
+
+// NewClient returns a client configured with the given options.
+func NewClient(opts ...Option) *Client {
+	c := &Client{
+		baseURL:   DefaultBaseURL,
+		userAgent: DefaultUserAgent,
+		retry:     DefaultRetryPolicy,
+		sleep:     sleep.Context,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	switch {
+	case c.httpClient == nil:
+		timeout := c.timeout
+		if timeout == 0 {
+			timeout = DefaultTimeout
+		}
+		c.httpClient = &http.Client{Timeout: timeout}
+	case c.timeout != 0:
+		// Copy so the caller's client is left untouched
+		httpClient := *c.httpClient
+		httpClient.Timeout = c.timeout
+		c.httpClient = &httpClient
+	}
+
+	return c
+}
+
+// get performs a GET request against the API and returns the response body,
+// retrying transient failures according to the client's retry policy. A
+// Retry-After longer than the policy's MaxDelay is returned as an error
+// instead of waited for.
+func (c *Client) get(ctx context.Context, apiURL string, query url.Values) ([]byte, error) {
+	for attempt := 1; ; attempt++ {
+		body, err := c.do(ctx, apiURL, query)
+		if err == nil {
+			return body, nil
+		}
+		if attempt >= c.retry.MaxAttempts || ctx.Err() != nil || !isRetryable(err) {
+			return nil, err
+		}
+
+		delay := c.retry.backoff(attempt)
+		var apiErr *APIError
+		if errors.As(err, &apiErr) && apiErr.RetryAfter > 0 {
+			// Leave a long wait to the next poll rather than blocking it
+			if c.retry.MaxDelay > 0 && apiErr.RetryAfter > c.retry.MaxDelay {
+				return nil, err
+			}
+			delay = apiErr.RetryAfter
+		}
+		if err := c.sleep(ctx, delay); err != nil {
+			return nil, fmt.Errorf("waiting to retry: %w", err)
+		}
+	}
+}
+
+// do performs a single GET request.
+func (c *Client) do(ctx context.Context, apiURL string, query url.Values) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, apiURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("creating HTTP request: %w", err)
+	}
+
+	q := req.URL.Query()
+	for key, values := range query {
+		for _, value := range values {
+			q.Add(key, value)
+		}
+	}
+	if c.token != "" {
+		q.Set("token", c.token)
+	}
+	req.URL.RawQuery = q.Encode()
+	req.Header.Set("User-Agent", c.userAgent)
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		// The request URL carries the token in its query
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			urlErr.URL = apiURL
+		}
+		return nil, fmt.Errorf("sending HTTP request: %w", err)
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4*maxBodySnippet))
+		return nil, &APIError{
+			StatusCode: resp.StatusCode,
+			Endpoint:   apiURL,
+			Body:       bodySnippet(body),
+			RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()),
+		}
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("reading HTTP response: %w", err)
+	}
+
+	return body, nil
+}
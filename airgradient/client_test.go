@@ -0,0 +1,134 @@
+package airgradient
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewClient(t *testing.T) {
+	custom := &http.Client{Timeout: time.Minute}
+
+	testCases := []struct {
+		name            string
+		opts            []Option
+		expectedTimeout time.Duration
+	}{
+		{
+			"defaults",
+			nil,
+			DefaultTimeout,
+		},
+		{
+			"with-timeout",
+			[]Option{WithTimeout(3 * time.Second)},
+			3 * time.Second,
+		},
+		{
+			"with-http-client",
+			[]Option{WithHTTPClient(custom)},
+			time.Minute,
+		},
+		{
+			"with-http-client-and-timeout",
+			[]Option{WithHTTPClient(custom), WithTimeout(3 * time.Second)},
+			3 * time.Second,
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.name, func(t *testing.T) {
+			client := NewClient(tC.opts...)
+			assert.Equal(t, tC.expectedTimeout, client.httpClient.Timeout)
+		})
+	}
+
+	// The caller's client must not be modified
+	assert.Equal(t, time.Minute, custom.Timeout)
+}
+
+func TestCurrentRequest(t *testing.T) {
+	var gotPath, gotToken, gotUserAgent string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		gotToken = r.URL.Query().Get("token")
+		gotUserAgent = r.UserAgent()
+		http.ServeFile(w, r, "testdata/api-v1-locations-12345-measures-current.json")
+	}))
+	defer server.Close()
+
+	client := NewClient(
+		WithBaseURL(server.URL),
+		WithToken("SECRET-TOKEN"),
+		WithUserAgent("airdash/test"),
+	)
+	measures, err := client.Current(context.Background(), 12345)
+	require.NoError(t, err)
+
+	assert.Equal(t, "/locations/12345/measures/current", gotPath)
+	assert.Equal(t, "SECRET-TOKEN", gotToken)
+	assert.Equal(t, "airdash/test", gotUserAgent)
+	require.Len(t, measures, 1)
+	assert.Equal(t, "Test Loc", measures[0].LocationName)
+}
+
+func TestCurrentContextCanceled(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	client := NewClient(WithBaseURL(server.URL))
+	_, err := client.Current(ctx, 0)
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+}
+
+func TestCurrentTransportError(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	server.Close()
+
+	client := NewClient(
+		WithBaseURL(server.URL),
+		WithToken("SECRET-TOKEN"),
+		WithRetryPolicy(RetryPolicy{MaxAttempts: 1}),
+	)
+	_, err := client.Current(context.Background(), 12345)
+
+	var urlErr *url.Error
+	require.ErrorAs(t, err, &urlErr)
+	assert.Equal(t, server.URL+"/locations/12345/measures/current", urlErr.URL)
+	assert.NotContains(t, err.Error(), "SECRET-TOKEN")
+}
+
+func TestCurrentAPIError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		_, _ = w.Write([]byte("{\n  \"message\": \"Invalid token\"\n}"))
+	}))
+	defer server.Close()
+
+	client := NewClient(WithBaseURL(server.URL), WithToken("SECRET-TOKEN"))
+	_, err := client.Current(context.Background(), 12345)
+
+	var apiErr *APIError
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusUnauthorized, apiErr.StatusCode)
+	assert.Equal(t, server.URL+"/locations/12345/measures/current", apiErr.Endpoint)
+	assert.Equal(t, `{ "message": "Invalid token" }`, apiErr.Body)
+	assert.NotContains(t, err.Error(), "SECRET-TOKEN")
+	assert.ErrorIs(t, err, ErrUnauthorized)
+}
@@ -0,0 +1,69 @@
+package airgradient
+
+import "context"
+
+// PM2.5 corrections.
+const (
+	// CorrectionNone leaves PM2.5 readings as the sensor reports them.
+	CorrectionNone = "none"
+	// CorrectionEPA applies the US EPA humidity correction for Plantower
+	// sensors, as offered on the AirGradient dashboard.
+	CorrectionEPA = "epa"
+	// CorrectionDevice marks readings the monitor already compensated.
+	CorrectionDevice = "device"
+)
+
+// EPACorrectPM25 applies the US EPA correction for PurpleAir and other
+// Plantower PMS5003 based sensors to a PM2.5 reading in µg/m³ at the given
+// relative humidity in %. It is the piecewise formula of Barkjohn et al.,
+// extended in 2022 for smoke-level concentrations, which AirGradient uses
+// on its dashboard. The result is never negative.
+func EPACorrectPM25(pm25, rhum float64) float64 {
+	var corrected float64
+	switch {
+	case pm25 < 30:
+		corrected = 0.524*pm25 - 0.0862*rhum + 5.75
+	case pm25 < 50:
+		w := pm25/20 - 3.0/2
+		corrected = (0.786*w+0.524*(1-w))*pm25 - 0.0862*rhum + 5.75
+	case pm25 < 210:
+		corrected = 0.786*pm25 - 0.0862*rhum + 5.75
+	case pm25 < 260:
+		w := pm25/50 - 21.0/5
+		corrected = (0.69*w+0.786*(1-w))*pm25 - 0.0862*rhum*(1-w) + 2.966*w + 5.75*(1-w) + 8.84e-4*pm25*pm25*w
+	default:
+		corrected = 2.966 + 0.69*pm25 + 8.84e-4*pm25*pm25
+	}
+	return max(corrected, 0)
+}
+
+// CorrectPM25 returns the measures with the EPA correction applied to Pm02.
+// Readings that are already corrected, or that lack the humidity the
+// correction needs, are returned unchanged.
+func CorrectPM25(m Measures) Measures {
+	if m.Pm02Correction != "" || !m.Pm02.Valid || !m.Rhum.Valid {
+		return m
+	}
+	m.Pm02Raw = m.Pm02
+	m.Pm02 = NewValue(EPACorrectPM25(m.Pm02.Float64, m.Rhum.Float64))
+	m.Pm02Correction = CorrectionEPA
+	return m
+}
+
+// CorrectedSource applies the EPA PM2.5 correction to the measures of
+// another source.
+type CorrectedSource struct {
+	Source Source
+}
+
+// Current returns the corrected current measures of the source.
+func (s CorrectedSource) Current(ctx context.Context) ([]Measures, error) {
+	measures, err := s.Source.Current(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for i, m := range measures {
+		measures[i] = CorrectPM25(m)
+	}
+	return measures, nil
+}
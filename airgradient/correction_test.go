@@ -0,0 +1,105 @@
+package airgradient
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEPACorrectPM25(t *testing.T) {
+	testCases := []struct {
+		name     string
+		pm25     float64
+		rhum     float64
+		expected float64
+	}{
+		{"low", 10, 50, 6.68},
+		{"clamped-to-zero", 1, 90, 0},
+		{"blend-30-50", 40, 60, 26.778},
+		{"boundary-30", 30, 50, 17.16},
+		{"boundary-50", 50, 50, 40.74},
+		{"mid", 100, 40, 80.902},
+		{"blend-210-260", 235, 50, 200.04245},
+		{"boundary-260", 260, 50, 242.1244},
+		{"smoke", 300, 50, 289.526},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.name, func(t *testing.T) {
+			assert.InDelta(t, tC.expected, EPACorrectPM25(tC.pm25, tC.rhum), 1e-6)
+		})
+	}
+}
+
+func TestEPACorrectPM25IsContinuous(t *testing.T) {
+	for _, boundary := range []float64{30, 50, 210, 260} {
+		below := EPACorrectPM25(boundary-1e-9, 50)
+		above := EPACorrectPM25(boundary, 50)
+		assert.InDelta(t, below, above, 1e-6, "at %v µg/m³", boundary)
+	}
+}
+
+func TestCorrectPM25(t *testing.T) {
+	v := NewValue
+
+	testCases := []struct {
+		name     string
+		measures Measures
+		expected Measures
+	}{
+		{
+			"corrected",
+			Measures{Pm02: v(10), Rhum: v(50)},
+			Measures{Pm02: v(EPACorrectPM25(10, 50)), Rhum: v(50), Pm02Correction: CorrectionEPA, Pm02Raw: v(10)},
+		},
+		{
+			"no-humidity",
+			Measures{Pm02: v(10)},
+			Measures{Pm02: v(10)},
+		},
+		{
+			"no-pm25",
+			Measures{Rhum: v(50)},
+			Measures{Rhum: v(50)},
+		},
+		{
+			"compensated-by-the-device",
+			Measures{Pm02: v(2.36), Rhum: v(55), Pm02Correction: CorrectionDevice, Pm02Raw: v(3)},
+			Measures{Pm02: v(2.36), Rhum: v(55), Pm02Correction: CorrectionDevice, Pm02Raw: v(3)},
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.name, func(t *testing.T) {
+			assert.Equal(t, tC.expected, CorrectPM25(tC.measures))
+		})
+	}
+}
+
+type fakeSource struct {
+	measures []Measures
+	err      error
+}
+
+func (s fakeSource) Current(context.Context) ([]Measures, error) {
+	return s.measures, s.err
+}
+
+func TestCorrectedSource(t *testing.T) {
+	v := NewValue
+	source := CorrectedSource{Source: fakeSource{measures: []Measures{
+		{LocationID: 1, Pm02: v(100), Rhum: v(40)},
+		{LocationID: 2, Pm02: v(10)},
+	}}}
+
+	measures, err := source.Current(context.Background())
+	require.NoError(t, err)
+	assert.InDelta(t, 80.902, measures[0].Pm02.Float64, 1e-6)
+	assert.Equal(t, CorrectionEPA, measures[0].Pm02Correction)
+	assert.Equal(t, v(10), measures[1].Pm02)
+
+	boom := errors.New("boom")
+	_, err = CorrectedSource{Source: fakeSource{err: boom}}.Current(context.Background())
+	assert.ErrorIs(t, err, boom)
+}
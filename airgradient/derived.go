@@ -0,0 +1,79 @@
+package airgradient
+
+import "math"
+
+// Derived comfort metrics. They are computed from the temperature in °C and
+// the relative humidity in %, and temperatures are returned in °C.
+
+// DewPoint returns the dew point using the Magnus formula with the
+// Alduchov and Eskridge coefficients.
+func DewPoint(tempC, rhum float64) float64 {
+	const a, b = 17.625, 243.04
+	gamma := math.Log(rhum/100) + a*tempC/(b+tempC)
+	return b * gamma / (a - gamma)
+}
+
+// HeatIndex returns the apparent temperature using the US National Weather
+// Service algorithm: Steadman's simple formula for mild conditions and the
+// Rothfusz regression, with its low and high humidity adjustments, from
+// 80 °F up.
+func HeatIndex(tempC, rhum float64) float64 {
+	t := tempC*9/5 + 32
+	hi := 0.5 * (t + 61 + (t-68)*1.2 + rhum*0.094)
+	if (hi+t)/2 >= 80 {
+		hi = -42.379 + 2.04901523*t + 10.14333127*rhum -
+			0.22475541*t*rhum - 6.83783e-3*t*t - 5.481717e-2*rhum*rhum +
+			1.22874e-3*t*t*rhum + 8.5282e-4*t*rhum*rhum - 1.99e-6*t*t*rhum*rhum
+		switch {
+		case rhum < 13 && t >= 80 && t <= 112:
+			hi -= (13 - rhum) / 4 * math.Sqrt((17-math.Abs(t-95))/17)
+		case rhum > 85 && t >= 80 && t <= 87:
+			hi += (rhum - 85) / 10 * (87 - t) / 5
+		}
+	}
+	return (hi - 32) * 5 / 9
+}
+
+// Humidex returns the Canadian humidex, a dimensionless index on the
+// Celsius scale, from the dew point.
+func Humidex(tempC, rhum float64) float64 {
+	vapourPressure := 6.11 * math.Exp(5417.7530*(1/273.16-1/(273.15+DewPoint(tempC, rhum))))
+	return tempC + 0.5555*(vapourPressure-10)
+}
+
+// AbsoluteHumidity returns the mass of water vapour in g/m³.
+func AbsoluteHumidity(tempC, rhum float64) float64 {
+	saturation := 6.112 * math.Exp(17.67*tempC/(tempC+243.5))
+	return saturation * rhum * 2.1674 / (273.15 + tempC)
+}
+
+// WetBulb returns the wet-bulb temperature using Stull's 2011 empirical
+// formula, valid from 5 % to 99 % relative humidity and -20 °C to 50 °C.
+func WetBulb(tempC, rhum float64) float64 {
+	return tempC*math.Atan(0.151977*math.Sqrt(rhum+8.313659)) +
+		math.Atan(tempC+rhum) - math.Atan(rhum-1.676331) +
+		0.00391838*math.Pow(rhum, 1.5)*math.Atan(0.023101*rhum) - 4.686035
+}
+
+// derive applies f to the temperature and humidity, missing if either is.
+func (m Measures) derive(f func(tempC, rhum float64) float64) Value {
+	if !m.Atmp.Valid || !m.Rhum.Valid || m.Rhum.Float64 <= 0 {
+		return Value{}
+	}
+	return NewValue(f(m.Atmp.Float64, m.Rhum.Float64))
+}
+
+// DewPoint returns the dew point in °C of the measures.
+func (m Measures) DewPoint() Value { return m.derive(DewPoint) }
+
+// HeatIndex returns the heat index in °C of the measures.
+func (m Measures) HeatIndex() Value { return m.derive(HeatIndex) }
+
+// Humidex returns the humidex of the measures.
+func (m Measures) Humidex() Value { return m.derive(Humidex) }
+
+// AbsoluteHumidity returns the absolute humidity in g/m³ of the measures.
+func (m Measures) AbsoluteHumidity() Value { return m.derive(AbsoluteHumidity) }
+
+// WetBulb returns the wet-bulb temperature in °C of the measures.
+func (m Measures) WetBulb() Value { return m.derive(WetBulb) }
@@ -0,0 +1,52 @@
+package airgradient
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// celsius converts the NWS heat index table entries, which are in °F.
+func celsius(f float64) float64 { return (f - 32) * 5 / 9 }
+
+func TestDerivedMetrics(t *testing.T) {
+	testCases := []struct {
+		name     string
+		f        func(tempC, rhum float64) float64
+		tempC    float64
+		rhum     float64
+		expected float64
+		delta    float64
+	}{
+		{"dew-point", DewPoint, 20, 50, 9.26, 0.01},
+		{"dew-point-humid", DewPoint, 30, 70, 23.93, 0.01},
+		{"dew-point-saturated", DewPoint, 15, 100, 15, 1e-9},
+		{"heat-index-mild", HeatIndex, 20, 50, 19.36, 0.01},
+		{"heat-index-90F-70", HeatIndex, celsius(90), 70, celsius(106), 0.1},
+		{"heat-index-dry-adjustment", HeatIndex, celsius(100), 10, celsius(94.1), 0.1},
+		{"heat-index-humid-adjustment", HeatIndex, celsius(84), 90, celsius(98.3), 0.1},
+		{"humidex", Humidex, 30, 70, 41.2, 0.1},
+		{"humidex-mild", Humidex, 20, 50, 20.9, 0.1},
+		{"absolute-humidity", AbsoluteHumidity, 20, 50, 8.64, 0.01},
+		{"absolute-humidity-humid", AbsoluteHumidity, 30, 70, 21.25, 0.01},
+		{"wet-bulb-stull-example", WetBulb, 20, 50, 13.7, 0.01},
+		{"wet-bulb-humid", WetBulb, 30, 70, 25.6, 0.01},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.name, func(t *testing.T) {
+			assert.InDelta(t, tC.expected, tC.f(tC.tempC, tC.rhum), tC.delta)
+		})
+	}
+}
+
+func TestMeasuresDerived(t *testing.T) {
+	v := NewValue
+	m := Measures{Atmp: v(20), Rhum: v(50)}
+	assert.InDelta(t, 9.26, m.DewPoint().Float64, 0.01)
+	assert.True(t, m.HeatIndex().Valid)
+
+	for _, missing := range []Measures{{Atmp: v(20)}, {Rhum: v(50)}, {Atmp: v(20), Rhum: v(0)}} {
+		assert.Equal(t, Value{}, missing.DewPoint())
+		assert.Equal(t, Value{}, missing.WetBulb())
+	}
+}
@@ -0,0 +1,70 @@
+package airgradient
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Sentinel errors matched by *APIError with errors.Is.
+var (
+	// ErrUnauthorized means the token is missing, invalid or revoked (401/403).
+	ErrUnauthorized = errors.New("unauthorized")
+	// ErrNotFound means the location does not exist for the token (404).
+	ErrNotFound = errors.New("not found")
+	// ErrRateLimited means the API is throttling requests (429).
+	ErrRateLimited = errors.New("rate limited")
+	// ErrUnavailable means the API failed on its side (5xx).
+	ErrUnavailable = errors.New("API unavailable")
+	// ErrBadPayload is returned when the API response cannot be decoded.
+	ErrBadPayload = errors.New("error unmarshalling JSON")
+)
+
+// maxBodySnippet bounds how much of an error response is kept.
+const maxBodySnippet = 200
+
+// APIError is returned for a non-200 API response.
+type APIError struct {
+	// StatusCode is the HTTP status of the response.
+	StatusCode int
+	// Endpoint is the requested URL, without the token.
+	Endpoint string
+	// Body is the start of the response body, whitespace collapsed.
+	Body string
+	// RetryAfter is the delay requested by a Retry-After header, if any.
+	RetryAfter time.Duration
+}
+
+func (e *APIError) Error() string {
+	msg := fmt.Sprintf("HTTP %d from API %s", e.StatusCode, e.Endpoint)
+	if e.Body != "" {
+		msg += ": " + e.Body
+	}
+	return msg
+}
+
+// Is maps the status code to the matching sentinel error.
+func (e *APIError) Is(target error) bool {
+	switch target {
+	case ErrUnauthorized:
+		return e.StatusCode == http.StatusUnauthorized || e.StatusCode == http.StatusForbidden
+	case ErrNotFound:
+		return e.StatusCode == http.StatusNotFound
+	case ErrRateLimited:
+		return e.StatusCode == http.StatusTooManyRequests
+	case ErrUnavailable:
+		return e.StatusCode >= http.StatusInternalServerError
+	}
+	return false
+}
+
+// bodySnippet returns the start of body with whitespace collapsed.
+func bodySnippet(body []byte) string {
+	snippet := strings.Join(strings.Fields(string(body)), " ")
+	if len(snippet) > maxBodySnippet {
+		snippet = strings.ToValidUTF8(snippet[:maxBodySnippet], "") + "…"
+	}
+	return snippet
+}
@@ -0,0 +1,54 @@
+package airgradient
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAPIErrorIs(t *testing.T) {
+	sentinels := []error{ErrUnauthorized, ErrNotFound, ErrRateLimited, ErrUnavailable}
+
+	testCases := []struct {
+		name       string
+		statusCode int
+		expected   error
+	}{
+		{"401", http.StatusUnauthorized, ErrUnauthorized},
+		{"403", http.StatusForbidden, ErrUnauthorized},
+		{"404", http.StatusNotFound, ErrNotFound},
+		{"429", http.StatusTooManyRequests, ErrRateLimited},
+		{"500", http.StatusInternalServerError, ErrUnavailable},
+		{"503", http.StatusServiceUnavailable, ErrUnavailable},
+		{"400", http.StatusBadRequest, nil},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.name, func(t *testing.T) {
+			// Wrapped the way callers wrap client errors
+			err := fmt.Errorf("fetching measures: %w", &APIError{StatusCode: tC.statusCode})
+			for _, sentinel := range sentinels {
+				assert.Equal(t, sentinel == tC.expected, errors.Is(err, sentinel), sentinel)
+			}
+		})
+	}
+}
+
+func TestAPIErrorMessage(t *testing.T) {
+	err := &APIError{StatusCode: 404, Endpoint: "https://api.airgradient.com/public/api/v1/locations/1/measures/current"}
+	assert.Equal(t, "HTTP 404 from API https://api.airgradient.com/public/api/v1/locations/1/measures/current", err.Error())
+
+	err.Body = "Not Found"
+	assert.Equal(t, "HTTP 404 from API https://api.airgradient.com/public/api/v1/locations/1/measures/current: Not Found", err.Error())
+}
+
+func TestBodySnippet(t *testing.T) {
+	assert.Equal(t, "", bodySnippet(nil))
+	assert.Equal(t, "<html> <body>Error</body> </html>", bodySnippet([]byte("<html>\n  <body>Error</body>\n</html>\n")))
+
+	long := bodySnippet([]byte(strings.Repeat("a", 500)))
+	assert.Equal(t, strings.Repeat("a", maxBodySnippet)+"…", long)
+}
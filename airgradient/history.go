@@ -0,0 +1,70 @@
+package airgradient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"time"
+)
+
+const (
+	// MaxHistoryWindow is the longest time range the API serves in a single
+	// past measures request. Longer ranges are split into several requests.
+	MaxHistoryWindow = 10 * 24 * time.Hour
+	// historyTimeFormat is the from/to format of the past measures endpoint.
+	historyTimeFormat = "20060102T150405Z"
+)
+
+// ErrNoLocation is returned by History when no location ID is given; the
+// past measures endpoint serves a single location at a time.
+var ErrNoLocation = errors.New("a location ID is required")
+
+// pastURL returns the past measures URL of a location.
+func (c *Client) pastURL(locationID int) string {
+	return fmt.Sprintf("%s/locations/%d/measures/past", c.baseURL, locationID)
+}
+
+// History returns the measures of a location between from and to, ordered by
+// Timestamp. Ranges longer than MaxHistoryWindow are fetched window by window.
+func (c *Client) History(ctx context.Context, locationID int, from, to time.Time) ([]Measures, error) {
+	if locationID == 0 {
+		return nil, ErrNoLocation
+	}
+	if !from.Before(to) {
+		return nil, fmt.Errorf("invalid time range: %s is not before %s", from.Format(time.RFC3339), to.Format(time.RFC3339))
+	}
+
+	var series []Measures
+	for start := from; start.Before(to); start = start.Add(MaxHistoryWindow) {
+		end := start.Add(MaxHistoryWindow)
+		if end.After(to) {
+			end = to
+		}
+		query := url.Values{
+			"from": {start.UTC().Format(historyTimeFormat)},
+			"to":   {end.UTC().Format(historyTimeFormat)},
+		}
+		payload, err := c.get(ctx, c.pastURL(locationID), query)
+		if err != nil {
+			return nil, err
+		}
+
+		var window []Measures
+		if err := json.Unmarshal(payload, &window); err != nil {
+			return nil, ErrBadPayload
+		}
+		series = append(series, window...)
+	}
+
+	// Windows share their boundary, keep one reading per timestamp
+	slices.SortStableFunc(series, func(a, b Measures) int {
+		return a.Timestamp.Compare(b.Timestamp)
+	})
+	series = slices.CompactFunc(series, func(a, b Measures) bool {
+		return a.Timestamp.Equal(b.Timestamp)
+	})
+	return series, nil
+}
@@ -0,0 +1,86 @@
+package airgradient
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHistory(t *testing.T) {
+	var windows [][2]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/locations/12345/measures/past", r.URL.Path)
+		assert.Equal(t, "SECRET-TOKEN", r.URL.Query().Get("token"))
+		windows = append(windows, [2]string{r.URL.Query().Get("from"), r.URL.Query().Get("to")})
+		http.ServeFile(w, r, "testdata/api-v1-locations-12345-measures-past.json")
+	}))
+	defer server.Close()
+
+	client := NewClient(WithBaseURL(server.URL), WithToken("SECRET-TOKEN"))
+
+	testCases := []struct {
+		name            string
+		from            time.Time
+		to              time.Time
+		expectedWindows [][2]string
+	}{
+		{
+			"single-window",
+			time.Date(2023, 10, 9, 3, 0, 0, 0, time.UTC),
+			time.Date(2023, 10, 10, 3, 0, 0, 0, time.UTC),
+			[][2]string{{"20231009T030000Z", "20231010T030000Z"}},
+		},
+		{
+			"split-into-windows",
+			time.Date(2023, 9, 15, 0, 0, 0, 0, time.UTC),
+			time.Date(2023, 10, 10, 3, 0, 0, 0, time.UTC),
+			[][2]string{
+				{"20230915T000000Z", "20230925T000000Z"},
+				{"20230925T000000Z", "20231005T000000Z"},
+				{"20231005T000000Z", "20231010T030000Z"},
+			},
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.name, func(t *testing.T) {
+			windows = nil
+
+			series, err := client.History(context.Background(), 12345, tC.from, tC.to)
+			require.NoError(t, err)
+			assert.Equal(t, tC.expectedWindows, windows)
+
+			// Every window returns the same readings, they are deduplicated and ordered
+			require.Len(t, series, 3)
+			assert.Equal(t, time.Date(2023, 10, 10, 0, 0, 0, 0, time.UTC), series[0].Timestamp)
+			assert.Equal(t, time.Date(2023, 10, 10, 2, 0, 0, 0, time.UTC), series[2].Timestamp)
+			assert.Equal(t, NewValue(1024), series[2].Rco2)
+		})
+	}
+}
+
+func TestHistoryInvalidArguments(t *testing.T) {
+	client := NewClient()
+	now := time.Now()
+
+	_, err := client.History(context.Background(), 0, now.Add(-time.Hour), now)
+	assert.ErrorIs(t, err, ErrNoLocation)
+
+	_, err = client.History(context.Background(), 12345, now, now.Add(-time.Hour))
+	assert.ErrorContains(t, err, "invalid time range")
+}
+
+func TestHistoryBadPayload(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.ServeFile(w, r, "testdata/incorrect-response-404.json")
+	}))
+	defer server.Close()
+
+	client := NewClient(WithBaseURL(server.URL))
+	_, err := client.History(context.Background(), 12345, time.Now().Add(-time.Hour), time.Now())
+	assert.ErrorIs(t, err, ErrBadPayload)
+}
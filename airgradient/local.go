@@ -0,0 +1,123 @@
+package airgradient
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+// localMeasures is the payload of a monitor's local /measures/current
+// endpoint. Its field names differ from the public API and it carries raw
+// and compensated variants of some readings.
+type localMeasures struct {
+	Pm01            Value  `json:"pm01"`
+	Pm02            Value  `json:"pm02"`
+	Pm10            Value  `json:"pm10"`
+	Pm02Compensated Value  `json:"pm02Compensated"`
+	Pm003Count      Value  `json:"pm003Count"`
+	Atmp            Value  `json:"atmp"`
+	AtmpCompensated Value  `json:"atmpCompensated"`
+	Rhum            Value  `json:"rhum"`
+	RhumCompensated Value  `json:"rhumCompensated"`
+	Rco2            Value  `json:"rco2"`
+	TvocIndex       Value  `json:"tvocIndex"`
+	NoxIndex        Value  `json:"noxIndex"`
+	Wifi            Value  `json:"wifi"`
+	LedMode         string `json:"ledMode"`
+	Serialno        string `json:"serialno"`
+	Firmware        string `json:"firmware"`
+	Model           string `json:"model"`
+}
+
+// LocalSource reads a single AirGradient monitor through its local HTTP API,
+// which needs neither a token nor internet access.
+type LocalSource struct {
+	client *Client
+	name   string
+	now    func() time.Time
+}
+
+// NewLocalSource returns a source for the monitor at host, either a host name
+// such as "airgradient_<serial>.local" or a base URL such as
+// "http://192.168.1.20". The name is used as the location name; when empty the
+// monitor's serial number is used. Options configure the underlying client,
+// e.g. its timeout or retry policy.
+func NewLocalSource(host, name string, opts ...Option) *LocalSource {
+	opts = append([]Option{WithBaseURL(LocalBaseURL(host))}, opts...)
+	return &LocalSource{
+		client: NewClient(opts...),
+		name:   name,
+		now:    time.Now,
+	}
+}
+
+// LocalBaseURL turns a monitor host name into the base URL of its local API.
+func LocalBaseURL(host string) string {
+	host = strings.TrimSuffix(strings.TrimSpace(host), "/")
+	if strings.Contains(host, "://") {
+		return host
+	}
+	return "http://" + host
+}
+
+// Current returns the monitor's current measures.
+func (s *LocalSource) Current(ctx context.Context) ([]Measures, error) {
+	payload, err := s.client.get(ctx, s.client.baseURL+"/measures/current", nil)
+	if err != nil {
+		return nil, err
+	}
+	m, err := ParseLocalMeasures(payload, s.now())
+	if err != nil {
+		return nil, err
+	}
+	if s.name != "" {
+		m.LocationName = s.name
+	}
+	return []Measures{m}, nil
+}
+
+// ParseLocalMeasures decodes a local API payload. The local API does not
+// report when the reading was taken, so it is stamped with now. Compensated
+// PM2.5, temperature and humidity are preferred over the raw readings, as on
+// the AirGradient dashboard.
+func ParseLocalMeasures(payload []byte, now time.Time) (Measures, error) {
+	var local localMeasures
+	if err := json.Unmarshal(payload, &local); err != nil || local.Serialno == "" {
+		return Measures{}, ErrBadPayload
+	}
+
+	m := Measures{
+		LocationName:    local.Serialno,
+		Pm01:            local.Pm01,
+		Pm02:            firstValid(local.Pm02Compensated, local.Pm02),
+		Pm10:            local.Pm10,
+		Pm003Count:      local.Pm003Count,
+		Atmp:            firstValid(local.AtmpCompensated, local.Atmp),
+		Rhum:            firstValid(local.RhumCompensated, local.Rhum),
+		Rco2:            local.Rco2,
+		Wifi:            local.Wifi,
+		Timestamp:       now.UTC(),
+		LedMode:         local.LedMode,
+		Serialno:        local.Serialno,
+		FirmwareVersion: local.Firmware,
+		Model:           local.Model,
+		TvocIndex:       local.TvocIndex,
+		NoxIndex:        local.NoxIndex,
+	}
+	if local.Pm02Compensated.Valid {
+		m.Pm02Correction = CorrectionDevice
+		m.Pm02Raw = local.Pm02
+	}
+	return m, nil
+}
+
+// firstValid returns the first present value.
+func firstValid(values ...Value) Value {
+	for _, v := range values {
+		if v.Valid {
+			return v
+		}
+	}
+	return Value{}
+}
@@ -0,0 +1,139 @@
+package airgradient
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseLocalMeasures(t *testing.T) {
+	now := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	v := NewValue
+
+	testCases := []struct {
+		name        string
+		payloadFile string
+		expected    Measures
+		err         error
+	}{
+		{
+			"airgradient-one",
+			"testdata/local-measures-current.json",
+			Measures{
+				LocationName:    "84fce612f5b8",
+				Pm01:            v(2),
+				Pm02:            v(2.36),
+				Pm10:            v(3),
+				Pm003Count:      v(401.5),
+				Atmp:            v(24.36),
+				Rhum:            v(55.24),
+				Rco2:            v(447),
+				Wifi:            v(-47),
+				Timestamp:       now,
+				LedMode:         "co2",
+				Serialno:        "84fce612f5b8",
+				FirmwareVersion: "3.1.3",
+				Model:           "I-9PSL",
+				TvocIndex:       v(99),
+				NoxIndex:        v(1),
+				Pm02Correction:  CorrectionDevice,
+				Pm02Raw:         v(3),
+			},
+			nil,
+		},
+		{
+			"open-air-without-compensation",
+			"testdata/local-measures-current-open-air.json",
+			Measures{
+				LocationName:    "ecda3b1a2b3c",
+				Pm01:            v(1),
+				Pm02:            v(4),
+				Pm10:            v(5),
+				Pm003Count:      v(520),
+				Atmp:            v(18.2),
+				Rhum:            v(71),
+				Wifi:            v(-63),
+				Timestamp:       now,
+				Serialno:        "ecda3b1a2b3c",
+				FirmwareVersion: "3.1.3",
+				Model:           "O-1PST",
+			},
+			nil,
+		},
+		{
+			"html",
+			"testdata/incorrect-response-404.json",
+			Measures{},
+			ErrBadPayload,
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.name, func(t *testing.T) {
+			payload, err := os.ReadFile(tC.payloadFile)
+			require.NoError(t, err)
+
+			m, err := ParseLocalMeasures(payload, now)
+			assert.Equal(t, tC.err, err)
+			assert.Equal(t, tC.expected, m)
+		})
+	}
+}
+
+func TestLocalSource(t *testing.T) {
+	var gotPath, gotQuery string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		gotQuery = r.URL.RawQuery
+		http.ServeFile(w, r, "testdata/local-measures-current.json")
+	}))
+	defer server.Close()
+
+	testCases := []struct {
+		name         string
+		locationName string
+		expected     string
+	}{
+		{"serial-as-name", "", "84fce612f5b8"},
+		{"configured-name", "Living Room", "Living Room"},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.name, func(t *testing.T) {
+			var source Source = NewLocalSource(server.URL, tC.locationName)
+			measures, err := source.Current(context.Background())
+			require.NoError(t, err)
+
+			assert.Equal(t, "/measures/current", gotPath)
+			assert.Empty(t, gotQuery)
+			require.Len(t, measures, 1)
+			assert.Equal(t, tC.expected, measures[0].LocationName)
+			assert.Equal(t, NewValue(447), measures[0].Rco2)
+			assert.False(t, measures[0].Timestamp.IsZero())
+		})
+	}
+}
+
+func TestLocalBaseURL(t *testing.T) {
+	assert.Equal(t, "http://airgradient_84fce612f5b8.local", LocalBaseURL("airgradient_84fce612f5b8.local"))
+	assert.Equal(t, "http://192.168.1.20", LocalBaseURL("http://192.168.1.20/"))
+	assert.Equal(t, "https://sensor.example", LocalBaseURL(" https://sensor.example "))
+}
+
+func TestCloudSource(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/locations/12345/measures/current", r.URL.Path)
+		http.ServeFile(w, r, "testdata/api-v1-locations-12345-measures-current.json")
+	}))
+	defer server.Close()
+
+	var source Source = CloudSource{Client: NewClient(WithBaseURL(server.URL)), LocationID: 12345}
+	measures, err := source.Current(context.Background())
+	require.NoError(t, err)
+	require.Len(t, measures, 1)
+	assert.Equal(t, 12345, measures[0].LocationID)
+}
@@ -0,0 +1,39 @@
+package airgradient
+
+// Metric describes one sensor reading of Measures, or one metric derived
+// from them.
+type Metric struct {
+	// Name is the API field name, e.g. "pm02".
+	Name string
+	// Label is the name shown to people, e.g. "PM2.5".
+	Label string
+	// Unit is the reading's unit, empty for dimensionless indices.
+	Unit string
+	// Temperature is set for temperatures in °C, which consumers convert to
+	// the configured unit.
+	Temperature bool
+	// Value returns the reading from a set of measures.
+	Value func(Measures) Value
+}
+
+// Metrics lists every sensor reading of Measures, followed by the derived
+// comfort metrics, in a stable order, for code that handles all readings
+// alike such as exporters and statistics.
+var Metrics = []Metric{
+	{Name: "pm01", Label: "PM1", Unit: "µg/m³", Value: func(m Measures) Value { return m.Pm01 }},
+	{Name: "pm02", Label: "PM2.5", Unit: "µg/m³", Value: func(m Measures) Value { return m.Pm02 }},
+	{Name: "pm10", Label: "PM10", Unit: "µg/m³", Value: func(m Measures) Value { return m.Pm10 }},
+	{Name: "pm003Count", Label: "PM0.3 count", Unit: "particles/dL", Value: func(m Measures) Value { return m.Pm003Count }},
+	{Name: "atmp", Label: "Temperature", Unit: "°C", Temperature: true, Value: func(m Measures) Value { return m.Atmp }},
+	{Name: "rhum", Label: "Humidity", Unit: "%", Value: func(m Measures) Value { return m.Rhum }},
+	{Name: "rco2", Label: "CO2", Unit: "ppm", Value: func(m Measures) Value { return m.Rco2 }},
+	{Name: "tvoc", Label: "TVOC", Unit: "ppb", Value: func(m Measures) Value { return m.Tvoc }},
+	{Name: "tvocIndex", Label: "VOC index", Unit: "", Value: func(m Measures) Value { return m.TvocIndex }},
+	{Name: "noxIndex", Label: "NOx index", Unit: "", Value: func(m Measures) Value { return m.NoxIndex }},
+	{Name: "wifi", Label: "Wi-Fi signal", Unit: "dBm", Value: func(m Measures) Value { return m.Wifi }},
+	{Name: "dewPoint", Label: "Dew point", Unit: "°C", Temperature: true, Value: Measures.DewPoint},
+	{Name: "heatIndex", Label: "Heat index", Unit: "°C", Temperature: true, Value: Measures.HeatIndex},
+	{Name: "humidex", Label: "Humidex", Unit: "", Value: Measures.Humidex},
+	{Name: "absoluteHumidity", Label: "Absolute humidity", Unit: "g/m³", Value: Measures.AbsoluteHumidity},
+	{Name: "wetBulb", Label: "Wet bulb temperature", Unit: "°C", Temperature: true, Value: Measures.WetBulb},
+}
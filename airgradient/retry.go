@@ -0,0 +1,96 @@
+package airgradient
+
+import (
+	"errors"
+	"math"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RetryPolicy controls how the client retries transient failures.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts, including the first one.
+	// Values below 2 disable retries.
+	MaxAttempts int
+	// BaseDelay is the backoff before the first retry. It doubles on every
+	// further attempt.
+	BaseDelay time.Duration
+	// MaxDelay caps the backoff between attempts, and is the longest
+	// Retry-After the client waits for. Zero means no cap.
+	MaxDelay time.Duration
+}
+
+// DefaultRetryPolicy retries twice, waiting about half a second and then
+// about a second, which keeps a failed poll well within the default interval.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 3,
+	BaseDelay:   500 * time.Millisecond,
+	MaxDelay:    10 * time.Second,
+}
+
+// backoff returns the delay before the retry following the given attempt:
+// exponential in the attempt number, capped at MaxDelay, with the upper half
+// jittered so that several clients do not retry in lockstep.
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	delay := p.BaseDelay
+	for i := 1; i < attempt && delay < math.MaxInt64/2; i++ {
+		if p.MaxDelay > 0 && delay >= p.MaxDelay {
+			break
+		}
+		delay *= 2
+	}
+	if p.MaxDelay > 0 && delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+	if delay <= 0 {
+		return 0
+	}
+	half := delay / 2
+	return half + rand.N(delay-half+1) //nolint:gosec // Jitter does not need a cryptographic source
+}
+
+// isRetryable reports whether a failed request is safe and worth retrying.
+// All API calls are GETs, so transport failures such as timeouts and reset
+// connections are retried, as are rate limiting and gateway errors. Other
+// HTTP errors, including 500, are returned straight away.
+func isRetryable(err error) bool {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		switch apiErr.StatusCode {
+		case http.StatusTooManyRequests,
+			http.StatusBadGateway,
+			http.StatusServiceUnavailable,
+			http.StatusGatewayTimeout:
+			return true
+		}
+		return false
+	}
+
+	var urlErr *url.Error
+	return errors.As(err, &urlErr)
+}
+
+// parseRetryAfter parses a Retry-After header given either in seconds or as
+// an HTTP date. It returns 0 when the header is missing or invalid.
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds <= 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		if delay := date.Sub(now); delay > 0 {
+			return delay
+		}
+	}
+	return 0
+}
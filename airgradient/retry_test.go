@@ -0,0 +1,206 @@
+package airgradient
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ljagiello/airdash/internal/sleep"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newRetryTestClient returns a client for server that records the delays it
+// would have slept instead of sleeping.
+func newRetryTestClient(serverURL string, delays *[]time.Duration) *Client {
+	client := NewClient(
+		WithBaseURL(serverURL),
+		WithRetryPolicy(RetryPolicy{MaxAttempts: 3, BaseDelay: 100 * time.Millisecond, MaxDelay: 10 * time.Second}),
+	)
+	client.sleep = func(ctx context.Context, d time.Duration) error {
+		*delays = append(*delays, d)
+		return ctx.Err()
+	}
+	return client
+}
+
+func TestRetry(t *testing.T) {
+	testCases := []struct {
+		name             string
+		failures         []int
+		retryAfter       string
+		expectedAttempts int32
+		expectedStatus   int
+	}{
+		{
+			"success-first-attempt",
+			nil,
+			"",
+			1,
+			0,
+		},
+		{
+			"recovers-from-503",
+			[]int{http.StatusServiceUnavailable, http.StatusBadGateway},
+			"",
+			3,
+			0,
+		},
+		{
+			"gives-up-after-max-attempts",
+			[]int{http.StatusGatewayTimeout, http.StatusGatewayTimeout, http.StatusGatewayTimeout},
+			"",
+			3,
+			http.StatusGatewayTimeout,
+		},
+		{
+			"no-retry-on-500",
+			[]int{http.StatusInternalServerError},
+			"",
+			1,
+			http.StatusInternalServerError,
+		},
+		{
+			"no-retry-on-404",
+			[]int{http.StatusNotFound},
+			"",
+			1,
+			http.StatusNotFound,
+		},
+		{
+			"retry-on-429",
+			[]int{http.StatusTooManyRequests},
+			"7",
+			2,
+			0,
+		},
+		{
+			"retry-after-over-max-delay",
+			[]int{http.StatusTooManyRequests},
+			"3600",
+			1,
+			http.StatusTooManyRequests,
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.name, func(t *testing.T) {
+			var attempts atomic.Int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				n := int(attempts.Add(1))
+				if n <= len(tC.failures) {
+					if tC.retryAfter != "" {
+						w.Header().Set("Retry-After", tC.retryAfter)
+					}
+					w.WriteHeader(tC.failures[n-1])
+					return
+				}
+				http.ServeFile(w, r, "testdata/api-v1-locations-measures-current.json")
+			}))
+			defer server.Close()
+
+			var delays []time.Duration
+			client := newRetryTestClient(server.URL, &delays)
+			_, err := client.Current(context.Background(), 0)
+			if tC.expectedStatus == 0 {
+				require.NoError(t, err)
+			} else {
+				var apiErr *APIError
+				require.ErrorAs(t, err, &apiErr)
+				assert.Equal(t, tC.expectedStatus, apiErr.StatusCode)
+			}
+			assert.Equal(t, tC.expectedAttempts, attempts.Load())
+			assert.Len(t, delays, int(tC.expectedAttempts)-1)
+			if tC.retryAfter != "" && tC.expectedStatus == 0 {
+				assert.Equal(t, []time.Duration{7 * time.Second}, delays)
+			}
+		})
+	}
+}
+
+func TestRetryNetworkError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	serverURL := server.URL
+	server.Close()
+
+	var delays []time.Duration
+	client := newRetryTestClient(serverURL, &delays)
+	_, err := client.Current(context.Background(), 0)
+	assert.ErrorContains(t, err, "sending HTTP request")
+	assert.Len(t, delays, 2)
+}
+
+func TestRetryStopsWhenContextDone(t *testing.T) {
+	var attempts atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	client := NewClient(WithBaseURL(server.URL))
+	client.sleep = func(ctx context.Context, d time.Duration) error {
+		cancel()
+		return sleep.Context(ctx, d)
+	}
+
+	_, err := client.Current(ctx, 0)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, int32(1), attempts.Load())
+}
+
+func TestBackoff(t *testing.T) {
+	policy := RetryPolicy{MaxAttempts: 10, BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second}
+
+	testCases := []struct {
+		attempt int
+		max     time.Duration
+	}{
+		{1, 100 * time.Millisecond},
+		{2, 200 * time.Millisecond},
+		{3, 400 * time.Millisecond},
+		{4, 800 * time.Millisecond},
+		{5, time.Second},
+		{9, time.Second},
+	}
+	for _, tC := range testCases {
+		for range 20 {
+			delay := policy.backoff(tC.attempt)
+			assert.GreaterOrEqual(t, delay, tC.max/2)
+			assert.LessOrEqual(t, delay, tC.max)
+		}
+	}
+}
+
+func TestBackoffWithoutMaxDelay(t *testing.T) {
+	policy := RetryPolicy{MaxAttempts: 10, BaseDelay: 100 * time.Millisecond}
+	delay := policy.backoff(5)
+	assert.GreaterOrEqual(t, delay, 800*time.Millisecond)
+	assert.LessOrEqual(t, delay, 1600*time.Millisecond)
+	assert.Positive(t, policy.backoff(100))
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name     string
+		value    string
+		expected time.Duration
+	}{
+		{"empty", "", 0},
+		{"seconds", "30", 30 * time.Second},
+		{"negative", "-5", 0},
+		{"http-date", "Mon, 01 Jan 2024 12:01:00 GMT", time.Minute},
+		{"past-date", "Mon, 01 Jan 2024 11:00:00 GMT", 0},
+		{"invalid", "soon", 0},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.name, func(t *testing.T) {
+			assert.Equal(t, tC.expected, parseRetryAfter(tC.value, now))
+		})
+	}
+}
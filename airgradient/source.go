@@ -0,0 +1,21 @@
+package airgradient
+
+import "context"
+
+// Source provides the current measures of one or more locations, either from
+// the AirGradient cloud or directly from a monitor on the local network.
+type Source interface {
+	Current(ctx context.Context) ([]Measures, error)
+}
+
+// CloudSource reads a location, or every location when LocationID is 0,
+// from the AirGradient public API.
+type CloudSource struct {
+	Client     *Client
+	LocationID int
+}
+
+// Current returns the current measures from the public API.
+func (s CloudSource) Current(ctx context.Context) ([]Measures, error) {
+	return s.Client.Current(ctx, s.LocationID)
+}
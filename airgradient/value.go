@@ -0,0 +1,57 @@
+package airgradient
+
+import (
+	"bytes"
+	"encoding/json"
+	"strconv"
+)
+
+// Value is a measurement that may be missing, e.g. PM1 on a monitor without
+// a particle sensor. The API reports missing values as null.
+type Value struct {
+	Float64 float64
+	Valid   bool
+}
+
+// NewValue returns a present measurement.
+func NewValue(f float64) Value {
+	return Value{Float64: f, Valid: true}
+}
+
+// Ptr returns the value as a pointer, nil when missing.
+func (v Value) Ptr() *float64 {
+	if !v.Valid {
+		return nil
+	}
+	f := v.Float64
+	return &f
+}
+
+// String formats the value, using "—" when missing.
+func (v Value) String() string {
+	if !v.Valid {
+		return "—"
+	}
+	return strconv.FormatFloat(v.Float64, 'f', -1, 64)
+}
+
+// MarshalJSON encodes a missing value as null.
+func (v Value) MarshalJSON() ([]byte, error) {
+	if !v.Valid {
+		return []byte("null"), nil
+	}
+	return json.Marshal(v.Float64)
+}
+
+// UnmarshalJSON decodes null as a missing value.
+func (v *Value) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(bytes.TrimSpace(data), []byte("null")) {
+		*v = Value{}
+		return nil
+	}
+	if err := json.Unmarshal(data, &v.Float64); err != nil {
+		return err
+	}
+	v.Valid = true
+	return nil
+}
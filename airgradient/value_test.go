@@ -0,0 +1,59 @@
+package airgradient
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValueJSON(t *testing.T) {
+	testCases := []struct {
+		name     string
+		payload  string
+		expected Value
+	}{
+		{"null", `null`, Value{}},
+		{"zero", `0`, NewValue(0)},
+		{"float", `93.979355`, NewValue(93.979355)},
+		{"negative", `-58`, NewValue(-58)},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.name, func(t *testing.T) {
+			var v Value
+			require.NoError(t, json.Unmarshal([]byte(tC.payload), &v))
+			assert.Equal(t, tC.expected, v)
+
+			out, err := json.Marshal(v)
+			require.NoError(t, err)
+			assert.JSONEq(t, tC.payload, string(out))
+		})
+	}
+
+	var v Value
+	assert.Error(t, json.Unmarshal([]byte(`"abc"`), &v))
+}
+
+func TestValuePtrAndString(t *testing.T) {
+	assert.Nil(t, Value{}.Ptr())
+	assert.Equal(t, "—", Value{}.String())
+
+	v := NewValue(4.5)
+	require.NotNil(t, v.Ptr())
+	assert.InDelta(t, 4.5, *v.Ptr(), 0)
+	assert.Equal(t, "4.5", v.String())
+}
+
+func TestParseMeasuresNullValues(t *testing.T) {
+	payload := []byte(`{"locationId":12345,"pm01":null,"pm02":5,"pm10":null,"rco2":537,"firmwareVersion":null}`)
+
+	measures, err := ParseMeasures(payload)
+	require.NoError(t, err)
+	require.Len(t, measures, 1)
+	assert.False(t, measures[0].Pm01.Valid)
+	assert.False(t, measures[0].Pm10.Valid)
+	assert.Equal(t, NewValue(5), measures[0].Pm02)
+	assert.Equal(t, NewValue(537), measures[0].Rco2)
+	assert.Empty(t, measures[0].FirmwareVersion)
+}
@@ -0,0 +1,333 @@
+package alert
+
+import (
+	"cmp"
+	"fmt"
+	"maps"
+	"math"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/ljagiello/airdash/airgradient"
+	"github.com/ljagiello/airdash/render"
+)
+
+// State is the state an event reports.
+type State string
+
+// Event states.
+const (
+	Firing   State = "firing"
+	Resolved State = "resolved"
+)
+
+// Offline events report locations whose readings are older than the
+// maximum age set with WithMaxAge, or that could not be fetched as often as
+// set with WithMaxFailures, with the age in minutes as the value.
+const (
+	OfflineRule   = "offline"
+	OfflineMetric = "age"
+)
+
+// offlineKey is the state key rule of the offline events.
+const offlineKey = -1
+
+// Event reports that a rule started or stopped firing for a location.
+type Event struct {
+	Rule       string    `json:"rule"`
+	LocationID int       `json:"locationId"`
+	Location   string    `json:"location"`
+	Metric     string    `json:"metric"`
+	Unit       string    `json:"unit"`
+	Value      float64   `json:"value"`
+	Threshold  float64   `json:"threshold"`
+	State      State     `json:"state"`
+	Time       time.Time `json:"timestamp"`
+}
+
+// String describes the event for the menu, e.g.
+// "CO2 high · Meeting Room 1210 ppm".
+func (e Event) String() string {
+	value := strconv.FormatFloat(e.Value, 'f', -1, 64)
+	if e.Unit != "" {
+		value += " " + e.Unit
+	}
+	return fmt.Sprintf("%s · %s %s", e.Rule, e.Location, value)
+}
+
+// Engine evaluates rules against successive measures. It keeps the state of
+// every rule per location, so each firing and resolution is reported once.
+// An Engine is not safe for concurrent use.
+type Engine struct {
+	rules   []Rule
+	metrics []airgradient.Metric
+	now     func() time.Time
+	states  map[stateKey]*ruleState
+	// offline fires for locations that stopped reporting, nil when
+	// disabled.
+	offline *Rule
+	// maxFailures is how many fetches in a row fail before the locations
+	// last seen are offline, 0 when failures are not counted.
+	maxFailures int
+	failures    int
+	// seen are the latest measures of the locations offline checks.
+	seen map[int]airgradient.Measures
+}
+
+type stateKey struct {
+	rule       int
+	locationID int
+}
+
+type ruleState struct {
+	// breachedSince is when the threshold was first crossed in the current
+	// run, zero when it is not crossed.
+	breachedSince time.Time
+	firing        bool
+	lastFired     time.Time
+	// last is the latest evaluation of a firing rule, returned by Active.
+	last Event
+}
+
+// Option configures an Engine.
+type Option func(*Engine)
+
+// WithClock sets the clock the engine reads the time from, time.Now by
+// default.
+func WithClock(now func() time.Time) Option {
+	return func(e *Engine) {
+		e.now = now
+	}
+}
+
+// WithMetrics sets the metrics rules can refer to, airgradient.Metrics by
+// default, e.g. to compare temperatures in °F.
+func WithMetrics(metrics []airgradient.Metric) Option {
+	return func(e *Engine) {
+		e.metrics = metrics
+	}
+}
+
+// WithMaxAge reports a location offline once its readings are older than
+// maxAge, e.g. because the monitor lost power while the API keeps returning
+// its last readings, and back online with its next reading. Only the
+// locations matching the selectors are checked, all of them when there are
+// none.
+func WithMaxAge(maxAge time.Duration, locations []string) Option {
+	return func(e *Engine) {
+		e.offline = &Rule{
+			Name:       OfflineRule,
+			Locations:  locations,
+			Metric:     OfflineMetric,
+			Comparator: Above,
+			Threshold:  maxAge.Minutes(),
+		}
+	}
+}
+
+// WithMaxFailures reports the locations last seen offline once n fetches in
+// a row failed, see Failed. It is meant for sources that stamp readings
+// with the time they were fetched, e.g. a monitor's local API, whose
+// readings never grow old. It has no effect without WithMaxAge.
+func WithMaxFailures(n int) Option {
+	return func(e *Engine) {
+		e.maxFailures = n
+	}
+}
+
+// NewEngine returns an engine for the rules, or an error wrapping
+// ErrInvalidRule for the first rule it cannot evaluate.
+func NewEngine(rules []Rule, opts ...Option) (*Engine, error) {
+	e := &Engine{
+		metrics: airgradient.Metrics,
+		now:     time.Now,
+		states:  make(map[stateKey]*ruleState),
+		seen:    make(map[int]airgradient.Measures),
+	}
+	for _, opt := range opts {
+		opt(e)
+	}
+
+	e.rules = slices.Clone(rules)
+	for i := range e.rules {
+		if err := e.rules[i].validate(e.metrics); err != nil {
+			return nil, err
+		}
+	}
+	return e, nil
+}
+
+// Evaluate checks every rule against the measures and returns the rules
+// that started or stopped firing, ordered by rule and location, after any
+// offline events. A rule fires once its threshold has been crossed for
+// Rule.For and no earlier firing for the location is within Rule.Cooldown;
+// it resolves once the value is back across Rule.Clear. Locations or
+// readings that are missing leave a rule's state unchanged.
+func (e *Engine) Evaluate(measures []airgradient.Measures) []Event {
+	now := e.now()
+	var events []Event
+	if e.offline != nil {
+		e.failures = 0
+		for _, m := range airgradient.SelectLocations(measures, e.offline.Locations) {
+			if m.Timestamp.IsZero() {
+				continue
+			}
+			e.seen[m.LocationID] = m
+			age := math.Round(now.Sub(m.Timestamp).Minutes())
+			event := e.newEvent(e.offline, m, "min", age, now)
+			if e.evaluate(stateKey{rule: offlineKey, locationID: m.LocationID}, e.offline, &event) {
+				events = append(events, event)
+			}
+		}
+	}
+	for i := range e.rules {
+		rule := &e.rules[i]
+		metric := e.metric(rule.Metric)
+		for _, m := range airgradient.SelectLocations(measures, rule.Locations) {
+			value := metric.Value(m)
+			if !value.Valid {
+				continue
+			}
+			event := e.newEvent(rule, m, metric.Unit, value.Float64, now)
+			if e.evaluate(stateKey{rule: i, locationID: m.LocationID}, rule, &event) {
+				events = append(events, event)
+			}
+		}
+	}
+	return events
+}
+
+// Failed records a failed fetch and returns the offline events it causes:
+// with WithMaxFailures, every location last seen goes offline once that
+// many fetches in a row failed. The next successful Evaluate brings them
+// back online.
+func (e *Engine) Failed() []Event {
+	if e.offline == nil || e.maxFailures <= 0 {
+		return nil
+	}
+	e.failures++
+	if e.failures < e.maxFailures {
+		return nil
+	}
+
+	now := e.now()
+	var events []Event
+	for _, id := range slices.Sorted(maps.Keys(e.seen)) {
+		m := e.seen[id]
+		age := math.Round(now.Sub(m.Timestamp).Minutes())
+		event := e.newEvent(e.offline, m, "min", age, now)
+		if e.fire(stateKey{rule: offlineKey, locationID: id}, &event) {
+			events = append(events, event)
+		}
+	}
+	return events
+}
+
+func (e *Engine) newEvent(rule *Rule, m airgradient.Measures, unit string, value float64, now time.Time) Event {
+	return Event{
+		Rule:       rule.Name,
+		LocationID: m.LocationID,
+		Location:   render.LocationLabel(m),
+		Metric:     rule.Metric,
+		Unit:       unit,
+		Value:      value,
+		Threshold:  rule.Threshold,
+		Time:       now,
+	}
+}
+
+// evaluate advances the state of a rule for a location with the value of
+// event, sets the event's state and reports whether it changed.
+func (e *Engine) evaluate(key stateKey, rule *Rule, event *Event) bool {
+	state, ok := e.states[key]
+	if !ok {
+		state = &ruleState{}
+		e.states[key] = state
+	}
+
+	changed := state.update(rule, event.Value, event.Time)
+	event.State = Resolved
+	if state.firing {
+		event.State = Firing
+		state.last = *event
+	}
+	return changed
+}
+
+// fire sets a rule firing for a location regardless of the event's value
+// and reports whether it was not firing already.
+func (e *Engine) fire(key stateKey, event *Event) bool {
+	state, ok := e.states[key]
+	if !ok {
+		state = &ruleState{}
+		e.states[key] = state
+	}
+	event.State = Firing
+	changed := !state.firing
+	if changed {
+		state.firing = true
+		state.lastFired = event.Time
+	}
+	state.last = *event
+	return changed
+}
+
+// update advances the state with a new value and reports whether the rule
+// started or stopped firing.
+func (s *ruleState) update(rule *Rule, value float64, now time.Time) bool {
+	if s.firing {
+		if rule.cleared(value) {
+			s.firing = false
+			s.breachedSince = time.Time{}
+			return true
+		}
+		return false
+	}
+
+	if !rule.breached(value) {
+		s.breachedSince = time.Time{}
+		return false
+	}
+	if s.breachedSince.IsZero() {
+		s.breachedSince = now
+	}
+	if now.Sub(s.breachedSince) < rule.For {
+		return false
+	}
+	if !s.lastFired.IsZero() && now.Sub(s.lastFired) < rule.Cooldown {
+		return false
+	}
+	s.firing = true
+	s.lastFired = now
+	return true
+}
+
+// Active returns the latest firing event of every rule that is firing,
+// ordered by rule and location, with the value last evaluated.
+func (e *Engine) Active() []Event {
+	type active struct {
+		key   stateKey
+		event Event
+	}
+	var firing []active
+	for key, state := range e.states {
+		if state.firing {
+			firing = append(firing, active{key, state.last})
+		}
+	}
+	slices.SortFunc(firing, func(a, b active) int {
+		return cmp.Or(cmp.Compare(a.key.rule, b.key.rule), cmp.Compare(a.key.locationID, b.key.locationID))
+	})
+
+	events := make([]Event, len(firing))
+	for i, a := range firing {
+		events[i] = a.event
+	}
+	return events
+}
+
+func (e *Engine) metric(name string) airgradient.Metric {
+	i := slices.IndexFunc(e.metrics, func(m airgradient.Metric) bool { return m.Name == name })
+	return e.metrics[i]
+}
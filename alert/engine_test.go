@@ -0,0 +1,362 @@
+package alert
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ljagiello/airdash/airgradient"
+	"github.com/ljagiello/airdash/render"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeClock is a manually advanced clock for deterministic tests.
+type fakeClock struct {
+	now time.Time
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Date(2024, 6, 1, 9, 0, 0, 0, time.UTC)}
+}
+
+func (c *fakeClock) Now() time.Time { return c.now }
+
+func (c *fakeClock) Advance(d time.Duration) { c.now = c.now.Add(d) }
+
+func ptr(f float64) *float64 { return &f }
+
+func co2(locationID int, name string, value float64) airgradient.Measures {
+	return airgradient.Measures{LocationID: locationID, LocationName: name, Rco2: airgradient.NewValue(value)}
+}
+
+// step advances the clock, evaluates the CO2 reading of location 1 and
+// lists the states of the events it expects.
+type step struct {
+	advance  time.Duration
+	value    float64
+	expected []State
+}
+
+func TestEngine(t *testing.T) {
+	testCases := []struct {
+		name  string
+		rule  Rule
+		steps []step
+	}{
+		{
+			"fires-once-and-resolves",
+			Rule{Metric: "rco2", Threshold: 1000},
+			[]step{
+				{0, 900, nil},
+				{time.Minute, 1100, []State{Firing}},
+				{time.Minute, 1200, nil},
+				{time.Minute, 1001, nil},
+				{time.Minute, 1000, []State{Resolved}},
+				{time.Minute, 1000, nil},
+			},
+		},
+		{
+			"hysteresis",
+			Rule{Metric: "rco2", Threshold: 1000, Clear: ptr(800)},
+			[]step{
+				{0, 1100, []State{Firing}},
+				{time.Minute, 950, nil},
+				{time.Minute, 1050, nil},
+				{time.Minute, 801, nil},
+				{time.Minute, 800, []State{Resolved}},
+				{time.Minute, 950, nil},
+			},
+		},
+		{
+			"held-for-duration",
+			Rule{Metric: "rco2", Threshold: 1000, For: 5 * time.Minute},
+			[]step{
+				{0, 1100, nil},
+				{4 * time.Minute, 1100, nil},
+				{time.Minute, 1100, []State{Firing}},
+				{time.Minute, 900, []State{Resolved}},
+			},
+		},
+		{
+			"interrupted-breach-restarts-the-duration",
+			Rule{Metric: "rco2", Threshold: 1000, For: 5 * time.Minute},
+			[]step{
+				{0, 1100, nil},
+				{3 * time.Minute, 900, nil},
+				{time.Minute, 1100, nil},
+				{4 * time.Minute, 1100, nil},
+				{time.Minute, 1100, []State{Firing}},
+			},
+		},
+		{
+			"cooldown",
+			Rule{Metric: "rco2", Threshold: 1000, Cooldown: 30 * time.Minute},
+			[]step{
+				{0, 1100, []State{Firing}},
+				{time.Minute, 900, []State{Resolved}},
+				{time.Minute, 1100, nil},
+				{20 * time.Minute, 1100, nil},
+				{8 * time.Minute, 1100, []State{Firing}},
+			},
+		},
+		{
+			"below",
+			Rule{Metric: "rco2", Comparator: Below, Threshold: 400, Clear: ptr(450)},
+			[]step{
+				{0, 420, nil},
+				{time.Minute, 390, []State{Firing}},
+				{time.Minute, 440, nil},
+				{time.Minute, 450, []State{Resolved}},
+			},
+		},
+		{
+			"above-or-equal",
+			Rule{Metric: "rco2", Comparator: AboveOrEqual, Threshold: 1000},
+			[]step{
+				{0, 1000, []State{Firing}},
+				{time.Minute, 999, []State{Resolved}},
+			},
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.name, func(t *testing.T) {
+			clock := newFakeClock()
+			engine, err := NewEngine([]Rule{tC.rule}, WithClock(clock.Now))
+			require.NoError(t, err)
+
+			for i, s := range tC.steps {
+				clock.Advance(s.advance)
+				var states []State
+				for _, event := range engine.Evaluate([]airgradient.Measures{co2(1, "Office", s.value)}) {
+					states = append(states, event.State)
+				}
+				assert.Equal(t, s.expected, states, "step %d at %v ppm", i, s.value)
+			}
+		})
+	}
+}
+
+func TestEngineEvent(t *testing.T) {
+	clock := newFakeClock()
+	engine, err := NewEngine([]Rule{{Metric: "rco2", Threshold: 1000}}, WithClock(clock.Now))
+	require.NoError(t, err)
+
+	events := engine.Evaluate([]airgradient.Measures{co2(12345, "", 1210)})
+	assert.Equal(t, []Event{{
+		Rule:       "rco2 > 1000",
+		LocationID: 12345,
+		Location:   "Location 12345",
+		Metric:     "rco2",
+		Unit:       "ppm",
+		Value:      1210,
+		Threshold:  1000,
+		State:      Firing,
+		Time:       clock.Now(),
+	}}, events)
+}
+
+func TestEventString(t *testing.T) {
+	assert.Equal(t, "CO2 high · Meeting Room 1210 ppm", Event{Rule: "CO2 high", Location: "Meeting Room", Value: 1210, Unit: "ppm"}.String())
+	assert.Equal(t, "nox · Office 3", Event{Rule: "nox", Location: "Office", Value: 3}.String())
+}
+
+func TestEngineLocations(t *testing.T) {
+	clock := newFakeClock()
+	engine, err := NewEngine([]Rule{
+		{Name: "meeting room", Metric: "rco2", Threshold: 1000, Locations: []string{"Meeting Room"}},
+		{Name: "anywhere", Metric: "rco2", Threshold: 1500},
+	}, WithClock(clock.Now))
+	require.NoError(t, err)
+
+	events := engine.Evaluate([]airgradient.Measures{co2(1, "Meeting Room", 1200), co2(2, "Office", 1200)})
+	require.Len(t, events, 1)
+	assert.Equal(t, "meeting room", events[0].Rule)
+	assert.Equal(t, "Meeting Room", events[0].Location)
+
+	// Locations keep separate states
+	clock.Advance(time.Minute)
+	events = engine.Evaluate([]airgradient.Measures{co2(1, "Meeting Room", 1600), co2(2, "Office", 1600)})
+	require.Len(t, events, 2)
+	assert.Equal(t, []int{1, 2}, []int{events[0].LocationID, events[1].LocationID})
+	assert.Equal(t, []string{"anywhere", "anywhere"}, []string{events[0].Rule, events[1].Rule})
+
+	clock.Advance(time.Minute)
+	events = engine.Evaluate([]airgradient.Measures{co2(1, "Meeting Room", 1600), co2(2, "Office", 900)})
+	require.Len(t, events, 1)
+	assert.Equal(t, Resolved, events[0].State)
+	assert.Equal(t, 2, events[0].LocationID)
+}
+
+func TestEngineMissingReadings(t *testing.T) {
+	clock := newFakeClock()
+	engine, err := NewEngine([]Rule{{Metric: "rco2", Threshold: 1000, For: 2 * time.Minute}}, WithClock(clock.Now))
+	require.NoError(t, err)
+
+	assert.Empty(t, engine.Evaluate([]airgradient.Measures{co2(1, "Office", 1100)}))
+
+	// A missing reading or location neither breaks nor clears the run
+	clock.Advance(time.Minute)
+	assert.Empty(t, engine.Evaluate([]airgradient.Measures{{LocationID: 1}}))
+	clock.Advance(time.Minute)
+	assert.Empty(t, engine.Evaluate(nil))
+	clock.Advance(time.Minute)
+	assert.Len(t, engine.Evaluate([]airgradient.Measures{co2(1, "Office", 1100)}), 1)
+}
+
+func TestEngineConvertedMetrics(t *testing.T) {
+	clock := newFakeClock()
+	engine, err := NewEngine(
+		[]Rule{{Metric: "atmp", Threshold: 80}},
+		WithClock(clock.Now),
+		WithMetrics(render.ConvertMetrics(airgradient.Metrics, "F")),
+	)
+	require.NoError(t, err)
+
+	events := engine.Evaluate([]airgradient.Measures{{LocationID: 1, Atmp: airgradient.NewValue(28)}})
+	require.Len(t, events, 1)
+	assert.InDelta(t, 82.4, events[0].Value, 1e-9)
+	assert.Equal(t, "°F", events[0].Unit)
+}
+
+func TestEngineOffline(t *testing.T) {
+	clock := newFakeClock()
+	engine, err := NewEngine([]Rule{{Metric: "rco2", Threshold: 1000}}, WithClock(clock.Now), WithMaxAge(10*time.Minute, nil))
+	require.NoError(t, err)
+
+	reading := func(age time.Duration) []airgradient.Measures {
+		m := co2(1, "Office", 900)
+		m.Timestamp = clock.Now().Add(-age)
+		// Local monitors without a timestamp are never offline
+		return []airgradient.Measures{m, co2(2, "Lab", 900)}
+	}
+
+	assert.Empty(t, engine.Evaluate(reading(2*time.Minute)))
+
+	// The API keeps returning the last reading once the monitor is gone
+	clock.Advance(10 * time.Minute)
+	assert.Empty(t, engine.Evaluate(reading(10*time.Minute)))
+	clock.Advance(time.Minute)
+	events := engine.Evaluate(reading(11 * time.Minute))
+	assert.Equal(t, []Event{{
+		Rule:       OfflineRule,
+		LocationID: 1,
+		Location:   "Office",
+		Metric:     OfflineMetric,
+		Unit:       "min",
+		Value:      11,
+		Threshold:  10,
+		State:      Firing,
+		Time:       clock.Now(),
+	}}, events)
+	assert.Equal(t, "offline · Office 11 min", events[0].String())
+
+	clock.Advance(time.Minute)
+	assert.Empty(t, engine.Evaluate(reading(12*time.Minute)))
+	require.Len(t, engine.Active(), 1)
+	assert.InDelta(t, 12.0, engine.Active()[0].Value, 0)
+
+	// Back online with a new reading
+	clock.Advance(time.Minute)
+	events = engine.Evaluate(reading(time.Minute))
+	require.Len(t, events, 1)
+	assert.Equal(t, Resolved, events[0].State)
+	assert.Empty(t, engine.Active())
+}
+
+func TestEngineOfflineSelectedLocations(t *testing.T) {
+	clock := newFakeClock()
+	engine, err := NewEngine(nil, WithClock(clock.Now), WithMaxAge(10*time.Minute, []string{"Office"}))
+	require.NoError(t, err)
+
+	office, lab := co2(1, "Office", 900), co2(2, "Lab", 900)
+	office.Timestamp = clock.Now().Add(-time.Hour)
+	lab.Timestamp = clock.Now().Add(-time.Hour)
+
+	events := engine.Evaluate([]airgradient.Measures{office, lab})
+	require.Len(t, events, 1)
+	assert.Equal(t, "Office", events[0].Location)
+}
+
+func TestEngineOfflineFailures(t *testing.T) {
+	clock := newFakeClock()
+	engine, err := NewEngine(nil, WithClock(clock.Now), WithMaxAge(10*time.Minute, nil), WithMaxFailures(3))
+	require.NoError(t, err)
+
+	// Local readings are stamped when they are fetched
+	reading := func() []airgradient.Measures {
+		m := co2(0, "Living Room", 900)
+		m.Timestamp = clock.Now()
+		return []airgradient.Measures{m}
+	}
+	assert.Empty(t, engine.Failed(), "nothing seen yet")
+	assert.Empty(t, engine.Evaluate(reading()))
+
+	for range 2 {
+		clock.Advance(time.Minute)
+		assert.Empty(t, engine.Failed())
+	}
+	clock.Advance(time.Minute)
+	events := engine.Failed()
+	assert.Equal(t, []Event{{
+		Rule:      OfflineRule,
+		Location:  "Living Room",
+		Metric:    OfflineMetric,
+		Unit:      "min",
+		Value:     3,
+		Threshold: 10,
+		State:     Firing,
+		Time:      clock.Now(),
+	}}, events)
+	clock.Advance(time.Minute)
+	assert.Empty(t, engine.Failed(), "fires once")
+	require.Len(t, engine.Active(), 1)
+
+	// Back online with the next successful fetch
+	clock.Advance(time.Minute)
+	events = engine.Evaluate(reading())
+	require.Len(t, events, 1)
+	assert.Equal(t, Resolved, events[0].State)
+
+	// A success resets the count
+	assert.Empty(t, engine.Failed())
+	assert.Empty(t, engine.Failed())
+	assert.Empty(t, engine.Evaluate(reading()))
+	assert.Empty(t, engine.Failed())
+}
+
+func TestEngineActive(t *testing.T) {
+	clock := newFakeClock()
+	engine, err := NewEngine([]Rule{{Metric: "rco2", Threshold: 1000}}, WithClock(clock.Now))
+	require.NoError(t, err)
+	assert.Empty(t, engine.Active())
+
+	engine.Evaluate([]airgradient.Measures{co2(2, "Office", 1100), co2(1, "Lab", 1300)})
+	clock.Advance(time.Minute)
+	engine.Evaluate([]airgradient.Measures{co2(2, "Office", 1150), co2(1, "Lab", 900)})
+
+	active := engine.Active()
+	require.Len(t, active, 1)
+	assert.Equal(t, "Office", active[0].Location)
+	assert.InDelta(t, 1150.0, active[0].Value, 0)
+	assert.Equal(t, Firing, active[0].State)
+}
+
+func TestNewEngineInvalidRules(t *testing.T) {
+	testCases := []struct {
+		name string
+		rule Rule
+		err  string
+	}{
+		{"unknown-metric", Rule{Metric: "ozone", Threshold: 1}, `invalid alert rule "ozone > 1": unknown metric "ozone"`},
+		{"unknown-comparator", Rule{Metric: "rco2", Comparator: "!=", Threshold: 1}, `invalid alert rule "rco2 != 1": unknown comparator "!=", expected one of [> >= < <=]`},
+		{"negative-duration", Rule{Name: "co2", Metric: "rco2", For: -time.Minute}, `invalid alert rule "co2": for and cooldown cannot be negative`},
+		{"clear-past-threshold", Rule{Name: "co2", Metric: "rco2", Threshold: 1000, Clear: ptr(1200)}, `invalid alert rule "co2": clear 1200 is past the threshold 1000`},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.name, func(t *testing.T) {
+			_, err := NewEngine([]Rule{tC.rule})
+			assert.ErrorIs(t, err, ErrInvalidRule)
+			assert.EqualError(t, err, tC.err)
+		})
+	}
+}
@@ -0,0 +1,111 @@
+// Package alert evaluates threshold rules against measures and reports when
+// they start and stop firing.
+package alert
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/ljagiello/airdash/airgradient"
+)
+
+// Comparators a rule can use.
+const (
+	Above        = ">"
+	AboveOrEqual = ">="
+	Below        = "<"
+	BelowOrEqual = "<="
+)
+
+var comparators = []string{Above, AboveOrEqual, Below, BelowOrEqual}
+
+// ErrInvalidRule is returned by NewEngine for a rule it cannot evaluate.
+var ErrInvalidRule = errors.New("invalid alert rule")
+
+// Rule is an alert on one metric, as configured in config.yaml:
+//
+//	alerts:
+//	  - name: CO2 high
+//	    metric: rco2
+//	    comparator: ">"
+//	    threshold: 1000
+//	    clear: 800
+//	    for: 5m
+//	    cooldown: 30m
+//	    locations: [Meeting Room]
+type Rule struct {
+	// Name identifies the rule in events, defaulting to e.g. "rco2 > 1000".
+	Name string `yaml:"name"`
+	// Metric is the name of an airgradient.Metrics entry, e.g. "rco2".
+	Metric string `yaml:"metric"`
+	// Comparator is one of ">", ">=", "<" and "<=", defaulting to ">".
+	Comparator string `yaml:"comparator"`
+	// Threshold is the value the metric is compared with.
+	Threshold float64 `yaml:"threshold"`
+	// Clear is the threshold a firing alert has to cross back over to
+	// resolve, for hysteresis. It defaults to Threshold.
+	Clear *float64 `yaml:"clear"`
+	// For is how long the threshold has to be crossed before the alert
+	// fires.
+	For time.Duration `yaml:"for"`
+	// Cooldown is the minimum time between two firings of the alert for the
+	// same location.
+	Cooldown time.Duration `yaml:"cooldown"`
+	// Locations limits the rule to these locations, by ID or name. Empty
+	// means every location.
+	Locations []string `yaml:"locations"`
+}
+
+// validate fills in the defaults and checks the rule against the metrics.
+func (r *Rule) validate(metrics []airgradient.Metric) error {
+	if r.Comparator == "" {
+		r.Comparator = Above
+	}
+	if r.Name == "" {
+		r.Name = fmt.Sprintf("%s %s %s", r.Metric, r.Comparator, strconv.FormatFloat(r.Threshold, 'f', -1, 64))
+	}
+
+	if !slices.ContainsFunc(metrics, func(m airgradient.Metric) bool { return m.Name == r.Metric }) {
+		return fmt.Errorf("%w %q: unknown metric %q", ErrInvalidRule, r.Name, r.Metric)
+	}
+	if !slices.Contains(comparators, r.Comparator) {
+		return fmt.Errorf("%w %q: unknown comparator %q, expected one of %v", ErrInvalidRule, r.Name, r.Comparator, comparators)
+	}
+	if r.For < 0 || r.Cooldown < 0 {
+		return fmt.Errorf("%w %q: for and cooldown cannot be negative", ErrInvalidRule, r.Name)
+	}
+	if r.Clear != nil && r.breached(*r.Clear) && *r.Clear != r.Threshold {
+		return fmt.Errorf("%w %q: clear %v is past the threshold %v", ErrInvalidRule, r.Name, *r.Clear, r.Threshold)
+	}
+	return nil
+}
+
+// breached reports whether the value crosses the threshold.
+func (r *Rule) breached(value float64) bool {
+	return compare(r.Comparator, value, r.Threshold)
+}
+
+// cleared reports whether the value is back across the clear threshold.
+func (r *Rule) cleared(value float64) bool {
+	clearAt := r.Threshold
+	if r.Clear != nil {
+		clearAt = *r.Clear
+	}
+	return !compare(r.Comparator, value, clearAt)
+}
+
+func compare(comparator string, value, threshold float64) bool {
+	switch comparator {
+	case Above:
+		return value > threshold
+	case AboveOrEqual:
+		return value >= threshold
+	case Below:
+		return value < threshold
+	default:
+		return value <= threshold
+	}
+}
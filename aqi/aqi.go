@@ -0,0 +1,196 @@
+// Package aqi converts particulate matter concentrations into air quality
+// indices: the US EPA AQI and a number of international standards.
+//
+// Only particulate matter is covered: AirGradient monitors measure PM2.5 and
+// PM10 but none of the gases (ozone, CO, NO2, SO2) most indices also use.
+package aqi
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"math"
+	"slices"
+
+	"github.com/ljagiello/airdash/airgradient"
+)
+
+// Pollutants an index can be computed from.
+const (
+	PM25 = "PM2.5"
+	PM10 = "PM10"
+)
+
+// Category is a named index band with its official colour.
+type Category struct {
+	Name string
+	// Color is the colour of the band as a hex RGB string, e.g. "#00E400".
+	Color string
+}
+
+// Index is the index of a single pollutant, or the overall index when it is
+// the highest of several.
+type Index struct {
+	Value     int
+	Pollutant string
+	Category  Category
+}
+
+// Standard is an air quality index.
+type Standard interface {
+	// Label is the short name shown in the status bar, e.g. "AQI".
+	Label() string
+	// Index returns the overall index of the readings: the worst index of
+	// the pollutants present. It reports false if none of them is present.
+	Index(pm25, pm10 airgradient.Value) (Index, bool)
+}
+
+// Names of the standards, as used in the config.
+const (
+	StandardUSEPA  = "us-epa"
+	StandardEUCAQI = "eu-caqi"
+	StandardEUEAQI = "eu-eaqi"
+	StandardUKDAQI = "uk-daqi"
+	StandardINNAQI = "in-naqi"
+	StandardCNAQI  = "cn-aqi"
+	StandardCAAQHI = "ca-aqhi"
+	StandardAUNEPM = "au-nepm"
+)
+
+var standards = map[string]Standard{
+	StandardUSEPA:  usEPA,
+	StandardEUCAQI: euCAQI,
+	StandardEUEAQI: euEAQI,
+	StandardUKDAQI: ukDAQI,
+	StandardINNAQI: inNAQI,
+	StandardCNAQI:  cnAQI,
+	StandardCAAQHI: caAQHI,
+	StandardAUNEPM: auNEPM,
+}
+
+// ErrUnknownStandard is returned by Lookup for a name it does not know.
+var ErrUnknownStandard = errors.New("unknown AQI standard")
+
+// Lookup returns the standard with the given name. An empty name is the US
+// EPA AQI.
+func Lookup(name string) (Standard, error) {
+	if name == "" {
+		name = StandardUSEPA
+	}
+	if s, ok := standards[name]; ok {
+		return s, nil
+	}
+	return nil, fmt.Errorf("%w %q, expected one of %v", ErrUnknownStandard, name, Names())
+}
+
+// Names returns the names of the supported standards in alphabetical order.
+func Names() []string {
+	return slices.Sorted(maps.Keys(standards))
+}
+
+// scale is a Standard that indexes each pollutant with its own function and
+// maps index values onto categories.
+type scale struct {
+	label      string
+	pm25, pm10 func(concentration float64) int
+	bands      []band
+}
+
+// band is the category of index values up to and including upTo. The last
+// band of a scale is open-ended.
+type band struct {
+	upTo     int
+	category Category
+}
+
+func (s scale) Label() string { return s.label }
+
+func (s scale) Index(pm25, pm10 airgradient.Value) (Index, bool) {
+	var indices []Index
+	if pm25.Valid && s.pm25 != nil {
+		indices = append(indices, s.index(PM25, s.pm25, pm25.Float64))
+	}
+	if pm10.Valid && s.pm10 != nil {
+		indices = append(indices, s.index(PM10, s.pm10, pm10.Float64))
+	}
+	return Highest(indices...)
+}
+
+func (s scale) index(pollutant string, f func(float64) int, concentration float64) Index {
+	value := f(math.Max(concentration, 0))
+	return Index{Value: value, Pollutant: pollutant, Category: s.category(value)}
+}
+
+func (s scale) category(value int) Category {
+	for _, b := range s.bands {
+		if value <= b.upTo {
+			return b.category
+		}
+	}
+	return s.bands[len(s.bands)-1].category
+}
+
+// Highest returns the worst of the indices, reporting false when there are
+// none.
+func Highest(indices ...Index) (Index, bool) {
+	if len(indices) == 0 {
+		return Index{}, false
+	}
+	highest := indices[0]
+	for _, index := range indices[1:] {
+		if index.Value > highest.Value {
+			highest = index
+		}
+	}
+	return highest, true
+}
+
+// breakpoint maps the concentration range [cLow, cHigh] linearly onto the
+// index range [iLow, iHigh].
+type breakpoint struct {
+	cLow, cHigh float64
+	iLow, iHigh int
+}
+
+// interpolate returns the unrounded index of the concentration. Values
+// beyond the last breakpoint are extrapolated along it.
+func interpolate(breakpoints []breakpoint, concentration float64) float64 {
+	bp := breakpoints[len(breakpoints)-1]
+	for _, b := range breakpoints {
+		if concentration <= b.cHigh {
+			bp = b
+			break
+		}
+	}
+	return float64(bp.iHigh-bp.iLow)/(bp.cHigh-bp.cLow)*(concentration-bp.cLow) + float64(bp.iLow)
+}
+
+// linear indexes a concentration by interpolating between breakpoints after
+// truncating it to the given number of decimals, rounding to the nearest
+// integer.
+func linear(breakpoints []breakpoint, decimals int) func(float64) int {
+	return func(concentration float64) int {
+		return int(math.Round(interpolate(breakpoints, truncate(concentration, decimals))))
+	}
+}
+
+// banded indexes a concentration by the number of upper bounds it exceeds,
+// starting at 1.
+func banded(upperBounds []float64) func(float64) int {
+	return func(concentration float64) int {
+		value := 1
+		for _, upper := range upperBounds {
+			if concentration > upper {
+				value++
+			}
+		}
+		return value
+	}
+}
+
+// truncate drops the digits after the given number of decimals. The small
+// offset keeps values like 9.1, stored as 9.0999…, from truncating down.
+func truncate(f float64, decimals int) float64 {
+	scale := math.Pow(10, float64(decimals))
+	return math.Floor(f*scale+1e-9) / scale
+}
@@ -0,0 +1,100 @@
+package aqi
+
+import (
+	"testing"
+
+	"github.com/ljagiello/airdash/airgradient"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFromPM25(t *testing.T) {
+	testCases := []struct {
+		name          string
+		concentration float64
+		expected      int
+		category      Category
+	}{
+		{"zero", 0, 0, Good},
+		{"negative", -1.5, 0, Good},
+		{"good-upper", 9.0, 50, Good},
+		{"truncated-to-good", 9.09, 50, Good},
+		{"moderate-lower", 9.1, 51, Moderate},
+		{"moderate", 12.0, 56, Moderate},
+		{"moderate-upper", 35.4, 100, Moderate},
+		{"sensitive-lower", 35.5, 101, UnhealthyForSensitiveGroups},
+		{"sensitive-upper", 55.4, 150, UnhealthyForSensitiveGroups},
+		{"unhealthy-upper", 125.4, 200, Unhealthy},
+		{"very-unhealthy-upper", 225.4, 300, VeryUnhealthy},
+		{"hazardous-lower", 225.5, 301, Hazardous},
+		{"hazardous-upper", 325.4, 500, Hazardous},
+		{"beyond-the-scale", 400, 649, Hazardous},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.name, func(t *testing.T) {
+			assert.Equal(t, Index{Value: tC.expected, Pollutant: PM25, Category: tC.category}, FromPM25(tC.concentration))
+		})
+	}
+}
+
+func TestFromPM10(t *testing.T) {
+	testCases := []struct {
+		name          string
+		concentration float64
+		expected      int
+		category      Category
+	}{
+		{"good-upper", 54, 50, Good},
+		{"truncated-to-good", 54.9, 50, Good},
+		{"moderate-lower", 55, 51, Moderate},
+		{"moderate", 100, 73, Moderate},
+		{"sensitive-lower", 155, 101, UnhealthyForSensitiveGroups},
+		{"very-unhealthy-upper", 424, 300, VeryUnhealthy},
+		{"hazardous-upper", 604, 500, Hazardous},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.name, func(t *testing.T) {
+			assert.Equal(t, Index{Value: tC.expected, Pollutant: PM10, Category: tC.category}, FromPM10(tC.concentration))
+		})
+	}
+}
+
+func TestUSEPAIndex(t *testing.T) {
+	v := airgradient.NewValue
+
+	testCases := []struct {
+		name     string
+		pm25     airgradient.Value
+		pm10     airgradient.Value
+		expected Index
+		ok       bool
+	}{
+		{"pm25-worse", v(12), v(20), Index{56, PM25, Moderate}, true},
+		{"pm10-worse", v(4), v(100), Index{73, PM10, Moderate}, true},
+		{"pm25-only", v(4), airgradient.Value{}, Index{22, PM25, Good}, true},
+		{"missing", airgradient.Value{}, airgradient.Value{}, Index{}, false},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.name, func(t *testing.T) {
+			index, ok := USEPA.Index(tC.pm25, tC.pm10)
+			assert.Equal(t, tC.ok, ok)
+			assert.Equal(t, tC.expected, index)
+		})
+	}
+}
+
+func TestLookup(t *testing.T) {
+	s, err := Lookup("")
+	require.NoError(t, err)
+	assert.Equal(t, USEPA.Label(), s.Label())
+
+	for _, name := range Names() {
+		s, err := Lookup(name)
+		require.NoError(t, err, name)
+		assert.NotEmpty(t, s.Label(), name)
+	}
+
+	_, err = Lookup("mars")
+	assert.ErrorIs(t, err, ErrUnknownStandard)
+	assert.EqualError(t, err, `unknown AQI standard "mars", expected one of [au-nepm ca-aqhi cn-aqi eu-caqi eu-eaqi in-naqi uk-daqi us-epa]`)
+}
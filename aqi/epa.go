@@ -0,0 +1,61 @@
+package aqi
+
+// US EPA AQI categories, from best to worst.
+var (
+	Good                        = Category{"Good", "#00E400"}
+	Moderate                    = Category{"Moderate", "#FFFF00"}
+	UnhealthyForSensitiveGroups = Category{"Unhealthy for Sensitive Groups", "#FF7E00"}
+	Unhealthy                   = Category{"Unhealthy", "#FF0000"}
+	VeryUnhealthy               = Category{"Very Unhealthy", "#8F3F97"}
+	Hazardous                   = Category{"Hazardous", "#7E0023"}
+)
+
+// usEPA is the US EPA AQI. Concentrations are truncated before indexing, to
+// one decimal for PM2.5 and to an integer for PM10, as the EPA specifies.
+var usEPA = scale{
+	label: "AQI",
+	pm25:  linear(usPM25Breakpoints, 1),
+	pm10:  linear(usPM10Breakpoints, 0),
+	bands: []band{
+		{50, Good},
+		{100, Moderate},
+		{150, UnhealthyForSensitiveGroups},
+		{200, Unhealthy},
+		{300, VeryUnhealthy},
+		{500, Hazardous},
+	},
+}
+
+// USEPA is the US EPA AQI with the 2024 PM2.5 breakpoints.
+var USEPA Standard = usEPA
+
+// usPM25Breakpoints are the 24-hour PM2.5 breakpoints in µg/m³ of the 2024
+// revision of the AQI.
+var usPM25Breakpoints = []breakpoint{
+	{0.0, 9.0, 0, 50},
+	{9.1, 35.4, 51, 100},
+	{35.5, 55.4, 101, 150},
+	{55.5, 125.4, 151, 200},
+	{125.5, 225.4, 201, 300},
+	{225.5, 325.4, 301, 500},
+}
+
+// usPM10Breakpoints are the 24-hour PM10 breakpoints in µg/m³.
+var usPM10Breakpoints = []breakpoint{
+	{0, 54, 0, 50},
+	{55, 154, 51, 100},
+	{155, 254, 101, 150},
+	{255, 354, 151, 200},
+	{355, 424, 201, 300},
+	{425, 604, 301, 500},
+}
+
+// FromPM25 returns the US EPA AQI of a PM2.5 concentration in µg/m³.
+func FromPM25(concentration float64) Index {
+	return usEPA.index(PM25, usEPA.pm25, concentration)
+}
+
+// FromPM10 returns the US EPA AQI of a PM10 concentration in µg/m³.
+func FromPM10(concentration float64) Index {
+	return usEPA.index(PM10, usEPA.pm10, concentration)
+}
@@ -0,0 +1,143 @@
+package aqi
+
+import (
+	"time"
+
+	"github.com/ljagiello/airdash/airgradient"
+)
+
+const (
+	// NowCastWindow is how far back the NowCast looks.
+	NowCastWindow = 12 * time.Hour
+	nowCastHours  = int(NowCastWindow / time.Hour)
+	// minNowCastWeight is the lowest weight factor the EPA allows for
+	// particulate matter.
+	minNowCastWeight = 0.5
+)
+
+type sample struct {
+	at    time.Time
+	value float64
+}
+
+// NowCast computes the EPA NowCast of a pollutant: a weighted average of the
+// hourly averages of the last 12 hours that reacts faster to changing air
+// than the 24-hour average the AQI breakpoints are defined for. Hours are
+// the 60-minute periods ending at the time of the calculation.
+//
+// The zero value is ready to use. A NowCast is not safe for concurrent use.
+type NowCast struct {
+	samples []sample
+}
+
+// Add records a concentration measured at the given time. Readings that are
+// not newer than the last one, such as the same API reading fetched twice,
+// are ignored.
+func (n *NowCast) Add(at time.Time, concentration float64) {
+	if len(n.samples) > 0 && !at.After(n.samples[len(n.samples)-1].at) {
+		return
+	}
+	n.samples = append(n.samples, sample{at: at, value: concentration})
+
+	cutoff := at.Add(-NowCastWindow)
+	i := 0
+	for i < len(n.samples) && !n.samples[i].at.After(cutoff) {
+		i++
+	}
+	n.samples = n.samples[i:]
+}
+
+// Value returns the NowCast concentration at now. As the EPA requires, it
+// reports false unless at least two of the three most recent hours have
+// readings.
+func (n *NowCast) Value(now time.Time) (float64, bool) {
+	var sums [nowCastHours]float64
+	var counts [nowCastHours]int
+	for _, s := range n.samples {
+		age := now.Sub(s.at)
+		if age < 0 || age >= NowCastWindow {
+			continue
+		}
+		hour := int(age / time.Hour)
+		sums[hour] += s.value
+		counts[hour]++
+	}
+
+	recent := 0
+	for hour := range 3 {
+		if counts[hour] > 0 {
+			recent++
+		}
+	}
+	if recent < 2 {
+		return 0, false
+	}
+
+	var averages [nowCastHours]float64
+	lowest, highest := -1.0, -1.0
+	for hour := range nowCastHours {
+		if counts[hour] == 0 {
+			continue
+		}
+		averages[hour] = sums[hour] / float64(counts[hour])
+		if lowest < 0 || averages[hour] < lowest {
+			lowest = averages[hour]
+		}
+		if averages[hour] > highest {
+			highest = averages[hour]
+		}
+	}
+	if highest <= 0 {
+		return 0, true
+	}
+
+	weight := max(lowest/highest, minNowCastWeight)
+	var weighted, weights float64
+	factor := 1.0
+	for hour := range nowCastHours {
+		if counts[hour] > 0 {
+			weighted += factor * averages[hour]
+			weights += factor
+		}
+		factor *= weight
+	}
+	return weighted / weights, true
+}
+
+// Tracker follows the particulate readings of the polling loop and indexes
+// their NowCast. The NowCast is defined for the US EPA AQI; other standards
+// are given the same concentrations. The zero value is ready to use. A
+// Tracker is not safe for concurrent use.
+type Tracker struct {
+	pm25, pm10 NowCast
+	latest     airgradient.Measures
+}
+
+// Add records a reading. Readings without a timestamp only count as the
+// latest reading.
+func (t *Tracker) Add(m airgradient.Measures) {
+	t.latest = m
+	if m.Timestamp.IsZero() {
+		return
+	}
+	if m.Pm02.Valid {
+		t.pm25.Add(m.Timestamp, m.Pm02.Float64)
+	}
+	if m.Pm10.Valid {
+		t.pm10.Add(m.Timestamp, m.Pm10.Float64)
+	}
+}
+
+// Index returns the index at now on the given standard. Until the NowCast
+// of a pollutant is available, e.g. during the first hours after start, the
+// latest reading is used instead.
+func (t *Tracker) Index(now time.Time, standard Standard) (Index, bool) {
+	pm25, pm10 := t.latest.Pm02, t.latest.Pm10
+	if v, ok := t.pm25.Value(now); ok {
+		pm25 = airgradient.NewValue(v)
+	}
+	if v, ok := t.pm10.Value(now); ok {
+		pm10 = airgradient.NewValue(v)
+	}
+	return standard.Index(pm25, pm10)
+}
@@ -0,0 +1,87 @@
+package aqi
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ljagiello/airdash/airgradient"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNowCast(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name     string
+		hourly   map[int][]float64 // readings by hours before now
+		expected float64
+		ok       bool
+	}{
+		{"steady", map[int][]float64{0: {10}, 1: {10}, 2: {10}, 11: {10}}, 10, true},
+		{"rising-min-weight", map[int][]float64{0: {30}, 1: {20}, 2: {10}}, 42.5 / 1.75, true},
+		{"weight-above-min", map[int][]float64{0: {10}, 1: {8}}, 16.4 / 1.8, true},
+		{"hourly-average", map[int][]float64{0: {8, 12}, 1: {10}}, 10, true},
+		{"gap-in-the-middle", map[int][]float64{0: {10}, 2: {10}, 5: {10}}, 10, true},
+		{"too-few-recent-hours", map[int][]float64{0: {10}, 3: {10}, 4: {10}}, 0, false},
+		{"outside-the-window", map[int][]float64{12: {10}, 13: {10}}, 0, false},
+		{"all-zero", map[int][]float64{0: {0}, 1: {0}}, 0, true},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.name, func(t *testing.T) {
+			var n NowCast
+			for hour := 13; hour >= 0; hour-- {
+				for i, v := range tC.hourly[hour] {
+					n.Add(now.Add(-time.Duration(hour)*time.Hour-30*time.Minute+time.Duration(i)*time.Minute), v)
+				}
+			}
+			value, ok := n.Value(now)
+			assert.Equal(t, tC.ok, ok)
+			assert.InDelta(t, tC.expected, value, 1e-9)
+		})
+	}
+}
+
+func TestNowCastIgnoresRepeatedReadings(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	var n NowCast
+	n.Add(now.Add(-90*time.Minute), 10)
+	n.Add(now.Add(-30*time.Minute), 20)
+	n.Add(now.Add(-30*time.Minute), 20)
+	n.Add(now.Add(-40*time.Minute), 50)
+
+	value, ok := n.Value(now)
+	assert.True(t, ok)
+	assert.InDelta(t, (20+0.5*10)/1.5, value, 1e-9)
+}
+
+func TestNowCastDropsOldReadings(t *testing.T) {
+	start := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	var n NowCast
+	for i := range 48 {
+		n.Add(start.Add(time.Duration(i)*time.Hour), 10)
+	}
+	assert.Len(t, n.samples, 12)
+}
+
+func TestTracker(t *testing.T) {
+	v := airgradient.NewValue
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	var tracker Tracker
+
+	_, ok := tracker.Index(now, USEPA)
+	assert.False(t, ok)
+
+	// Without NowCast history the latest reading is used
+	tracker.Add(airgradient.Measures{Pm02: v(40), Timestamp: now.Add(-5 * time.Minute)})
+	index, ok := tracker.Index(now, USEPA)
+	assert.True(t, ok)
+	assert.Equal(t, Index{112, PM25, UnhealthyForSensitiveGroups}, index)
+
+	// A clean hour before pulls the NowCast down: (40 + 0.5*4) / 1.5 = 28
+	tracker = Tracker{}
+	tracker.Add(airgradient.Measures{Pm02: v(4), Timestamp: now.Add(-65 * time.Minute)})
+	tracker.Add(airgradient.Measures{Pm02: v(40), Timestamp: now.Add(-5 * time.Minute)})
+	index, ok = tracker.Index(now, USEPA)
+	assert.True(t, ok)
+	assert.Equal(t, FromPM25(28), index)
+}
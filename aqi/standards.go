@@ -0,0 +1,196 @@
+package aqi
+
+import "math"
+
+// International standards. Where a standard averages over a day or an hour,
+// the concentration it is given is used as is; the caller decides what to
+// average over.
+
+// euCAQI is the European Common Air Quality Index (CiteairII, hourly
+// background grid), an open-ended scale where 100 is the start of "Very
+// high".
+var euCAQI = scale{
+	label: "CAQI",
+	pm25: linear([]breakpoint{
+		{0, 15, 0, 25},
+		{15, 30, 25, 50},
+		{30, 55, 50, 75},
+		{55, 110, 75, 100},
+	}, 1),
+	pm10: linear([]breakpoint{
+		{0, 25, 0, 25},
+		{25, 50, 25, 50},
+		{50, 90, 50, 75},
+		{90, 180, 75, 100},
+	}, 1),
+	bands: []band{
+		{25, Category{"Very low", "#79BC6A"}},
+		{50, Category{"Low", "#BBCF4C"}},
+		{75, Category{"Medium", "#EEC20B"}},
+		{100, Category{"High", "#F29305"}},
+		{math.MaxInt, Category{"Very high", "#E8416F"}},
+	},
+}
+
+// euEAQI is the European Environment Agency's European Air Quality Index
+// with the 2024 thresholds, which only has bands: 1 (Good) to 6 (Extremely
+// poor).
+var euEAQI = scale{
+	label: "EAQI",
+	pm25:  banded([]float64{5, 15, 50, 90, 140}),
+	pm10:  banded([]float64{15, 45, 120, 195, 270}),
+	bands: []band{
+		{1, Category{"Good", "#50F0E6"}},
+		{2, Category{"Fair", "#50CCAA"}},
+		{3, Category{"Moderate", "#F0E641"}},
+		{4, Category{"Poor", "#FF5050"}},
+		{5, Category{"Very poor", "#960032"}},
+		{6, Category{"Extremely poor", "#7D2181"}},
+	},
+}
+
+// ukDAQI is the UK Daily Air Quality Index, 1 to 10, defined on 24-hour
+// means rounded to whole µg/m³.
+var ukDAQI = scale{
+	label: "DAQI",
+	pm25:  rounded(banded([]float64{11, 23, 35, 41, 47, 53, 58, 64, 70})),
+	pm10:  rounded(banded([]float64{16, 33, 50, 58, 66, 75, 83, 91, 100})),
+	bands: []band{
+		{1, Category{"Low", "#9CFF9C"}},
+		{2, Category{"Low", "#31FF00"}},
+		{3, Category{"Low", "#31CF00"}},
+		{4, Category{"Moderate", "#FFFF00"}},
+		{5, Category{"Moderate", "#FFCF00"}},
+		{6, Category{"Moderate", "#FF9A00"}},
+		{7, Category{"High", "#FF6464"}},
+		{8, Category{"High", "#FF0000"}},
+		{9, Category{"High", "#990000"}},
+		{10, Category{"Very High", "#CE30FF"}},
+	},
+}
+
+// inNAQI is India's National Air Quality Index (CPCB). Its "Severe" band is
+// open-ended; it is interpolated up to 380 µg/m³ (PM2.5) and 510 µg/m³
+// (PM10) and extrapolated beyond.
+var inNAQI = scale{
+	label: "NAQI",
+	pm25: linear([]breakpoint{
+		{0, 30, 0, 50},
+		{31, 60, 51, 100},
+		{61, 90, 101, 200},
+		{91, 120, 201, 300},
+		{121, 250, 301, 400},
+		{251, 380, 401, 500},
+	}, 0),
+	pm10: linear([]breakpoint{
+		{0, 50, 0, 50},
+		{51, 100, 51, 100},
+		{101, 250, 101, 200},
+		{251, 350, 201, 300},
+		{351, 430, 301, 400},
+		{431, 510, 401, 500},
+	}, 0),
+	bands: []band{
+		{50, Category{"Good", "#00B050"}},
+		{100, Category{"Satisfactory", "#92D050"}},
+		{200, Category{"Moderate", "#FFFF00"}},
+		{300, Category{"Poor", "#FF9900"}},
+		{400, Category{"Very Poor", "#FF0000"}},
+		{500, Category{"Severe", "#C00000"}},
+	},
+}
+
+// cnAQI is China's AQI (HJ 633-2012) on 24-hour means. The standard rounds
+// the individual indices up.
+var cnAQI = scale{
+	label: "AQI-CN",
+	pm25: ceiled([]breakpoint{
+		{0, 35, 0, 50},
+		{35, 75, 50, 100},
+		{75, 115, 100, 150},
+		{115, 150, 150, 200},
+		{150, 250, 200, 300},
+		{250, 350, 300, 400},
+		{350, 500, 400, 500},
+	}),
+	pm10: ceiled([]breakpoint{
+		{0, 50, 0, 50},
+		{50, 150, 50, 100},
+		{150, 250, 100, 150},
+		{250, 350, 150, 200},
+		{350, 420, 200, 300},
+		{420, 500, 300, 400},
+		{500, 600, 400, 500},
+	}),
+	bands: []band{
+		{50, Category{"Excellent", "#00E400"}},
+		{100, Category{"Good", "#FFFF00"}},
+		{150, Category{"Lightly Polluted", "#FF7E00"}},
+		{200, Category{"Moderately Polluted", "#FF0000"}},
+		{300, Category{"Heavily Polluted", "#99004C"}},
+		{500, Category{"Severely Polluted", "#7E0023"}},
+	},
+}
+
+// caAQHI is Canada's AQHI+, the PM2.5-only amendment of the Air Quality
+// Health Index: the hourly concentration divided by 10 and rounded up, with
+// 11 standing for "10+". The full AQHI also needs ozone and NO2, which
+// AirGradient monitors do not measure.
+var caAQHI = scale{
+	label: "AQHI+",
+	pm25: func(concentration float64) int {
+		return max(1, int(math.Ceil(concentration/10)))
+	},
+	bands: []band{
+		{1, Category{"Low", "#00CCFF"}},
+		{2, Category{"Low", "#0099CC"}},
+		{3, Category{"Low", "#006699"}},
+		{4, Category{"Moderate", "#FFFF00"}},
+		{5, Category{"Moderate", "#FFCC00"}},
+		{6, Category{"Moderate", "#FF9933"}},
+		{7, Category{"High", "#FF6666"}},
+		{8, Category{"High", "#FF0000"}},
+		{9, Category{"High", "#CC0000"}},
+		{10, Category{"High", "#990000"}},
+		{11, Category{"Very High", "#660000"}},
+	},
+}
+
+// auNEPM is the Australian AQI: the concentration as a percentage of the
+// National Environment Protection Measure 24-hour standard (25 µg/m³ for
+// PM2.5, 50 µg/m³ for PM10), where 100 means the standard is met exactly.
+var auNEPM = scale{
+	label: "AQI-AU",
+	pm25:  percentOf(25),
+	pm10:  percentOf(50),
+	bands: []band{
+		{33, Category{"Very Good", "#31ADD3"}},
+		{66, Category{"Good", "#99B964"}},
+		{99, Category{"Fair", "#FFD236"}},
+		{149, Category{"Poor", "#EC783A"}},
+		{199, Category{"Very Poor", "#782D49"}},
+		{math.MaxInt, Category{"Hazardous", "#D04730"}},
+	},
+}
+
+// rounded rounds the concentration to whole µg/m³ before indexing it.
+func rounded(f func(float64) int) func(float64) int {
+	return func(concentration float64) int {
+		return f(math.Round(concentration))
+	}
+}
+
+// ceiled indexes a concentration by interpolating between breakpoints,
+// rounding up.
+func ceiled(breakpoints []breakpoint) func(float64) int {
+	return func(concentration float64) int {
+		return int(math.Ceil(interpolate(breakpoints, concentration) - 1e-9))
+	}
+}
+
+// percentOf indexes a concentration as a rounded percentage of a standard.
+func percentOf(standard float64) func(float64) int {
+	return func(concentration float64) int {
+		return int(math.Round(concentration / standard * 100))
+	}
+}
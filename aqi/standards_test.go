@@ -0,0 +1,109 @@
+package aqi
+
+import (
+	"testing"
+
+	"github.com/ljagiello/airdash/airgradient"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStandards(t *testing.T) {
+	v := airgradient.NewValue
+	none := airgradient.Value{}
+
+	testCases := []struct {
+		name     string
+		standard string
+		pm25     airgradient.Value
+		pm10     airgradient.Value
+		value    int
+		category string
+	}{
+		// EU CAQI: PM2.5 0-15-30-55-110, PM10 0-25-50-90-180 µg/m³
+		{"caqi-pm25-zero", StandardEUCAQI, v(0), none, 0, "Very low"},
+		{"caqi-pm25-very-low-upper", StandardEUCAQI, v(15), none, 25, "Very low"},
+		{"caqi-pm25-low", StandardEUCAQI, v(21), none, 35, "Low"},
+		{"caqi-pm25-medium-upper", StandardEUCAQI, v(55), none, 75, "Medium"},
+		{"caqi-pm25-high-upper", StandardEUCAQI, v(110), none, 100, "High"},
+		{"caqi-pm25-very-high", StandardEUCAQI, v(165), none, 125, "Very high"},
+		{"caqi-pm10-medium", StandardEUCAQI, none, v(70), 63, "Medium"},
+		{"caqi-pm10-worse", StandardEUCAQI, v(10), v(90), 75, "Medium"},
+
+		// EU EAQI: PM2.5 5-15-50-90-140, PM10 15-45-120-195-270 µg/m³
+		{"eaqi-pm25-good", StandardEUEAQI, v(5), none, 1, "Good"},
+		{"eaqi-pm25-fair", StandardEUEAQI, v(5.1), none, 2, "Fair"},
+		{"eaqi-pm25-moderate", StandardEUEAQI, v(50), none, 3, "Moderate"},
+		{"eaqi-pm25-poor", StandardEUEAQI, v(90), none, 4, "Poor"},
+		{"eaqi-pm25-very-poor", StandardEUEAQI, v(140), none, 5, "Very poor"},
+		{"eaqi-pm25-extremely-poor", StandardEUEAQI, v(141), none, 6, "Extremely poor"},
+		{"eaqi-pm10-poor", StandardEUEAQI, v(1), v(195), 4, "Poor"},
+
+		// UK DAQI: PM2.5 11-23-35-41-47-53-58-64-70, PM10 16-33-50-58-66-75-83-91-100 µg/m³
+		{"daqi-pm25-1", StandardUKDAQI, v(11), none, 1, "Low"},
+		{"daqi-pm25-rounds-down", StandardUKDAQI, v(11.4), none, 1, "Low"},
+		{"daqi-pm25-rounds-up", StandardUKDAQI, v(11.5), none, 2, "Low"},
+		{"daqi-pm25-4", StandardUKDAQI, v(36), none, 4, "Moderate"},
+		{"daqi-pm25-7", StandardUKDAQI, v(54), none, 7, "High"},
+		{"daqi-pm25-9", StandardUKDAQI, v(70), none, 9, "High"},
+		{"daqi-pm25-10", StandardUKDAQI, v(71), none, 10, "Very High"},
+		{"daqi-pm10-6", StandardUKDAQI, v(1), v(75), 6, "Moderate"},
+
+		// India NAQI: PM2.5 30-60-90-120-250, PM10 50-100-250-350-430 µg/m³
+		{"naqi-pm25-good-upper", StandardINNAQI, v(30), none, 50, "Good"},
+		{"naqi-pm25-satisfactory", StandardINNAQI, v(31), none, 51, "Satisfactory"},
+		{"naqi-pm25-moderate-upper", StandardINNAQI, v(90), none, 200, "Moderate"},
+		{"naqi-pm25-poor", StandardINNAQI, v(91), none, 201, "Poor"},
+		{"naqi-pm25-very-poor-upper", StandardINNAQI, v(250), none, 400, "Very Poor"},
+		{"naqi-pm25-severe", StandardINNAQI, v(251), none, 401, "Severe"},
+		{"naqi-pm10-moderate", StandardINNAQI, none, v(175), 150, "Moderate"},
+
+		// China AQI: PM2.5 35-75-115-150-250-350-500, PM10 50-150-250-350-420-500-600 µg/m³
+		{"cn-pm25-excellent-upper", StandardCNAQI, v(35), none, 50, "Excellent"},
+		{"cn-pm25-good-rounds-up", StandardCNAQI, v(35.1), none, 51, "Good"},
+		{"cn-pm25-lightly", StandardCNAQI, v(115), none, 150, "Lightly Polluted"},
+		{"cn-pm25-moderately", StandardCNAQI, v(150), none, 200, "Moderately Polluted"},
+		{"cn-pm25-heavily", StandardCNAQI, v(250), none, 300, "Heavily Polluted"},
+		{"cn-pm25-severely", StandardCNAQI, v(500), none, 500, "Severely Polluted"},
+		{"cn-pm10-heavily", StandardCNAQI, v(1), v(385), 250, "Heavily Polluted"},
+
+		// Canada AQHI+: PM2.5 / 10, rounded up
+		{"aqhi-zero", StandardCAAQHI, v(0), none, 1, "Low"},
+		{"aqhi-low-upper", StandardCAAQHI, v(30), none, 3, "Low"},
+		{"aqhi-moderate", StandardCAAQHI, v(30.1), none, 4, "Moderate"},
+		{"aqhi-high", StandardCAAQHI, v(100), none, 10, "High"},
+		{"aqhi-very-high", StandardCAAQHI, v(101), none, 11, "Very High"},
+		{"aqhi-ignores-pm10", StandardCAAQHI, v(5), v(500), 1, "Low"},
+
+		// Australia: percentage of 25 µg/m³ PM2.5 and 50 µg/m³ PM10
+		{"au-pm25-very-good", StandardAUNEPM, v(8), none, 32, "Very Good"},
+		{"au-pm25-good", StandardAUNEPM, v(10), none, 40, "Good"},
+		{"au-pm25-fair", StandardAUNEPM, v(20), none, 80, "Fair"},
+		{"au-pm25-poor", StandardAUNEPM, v(25), none, 100, "Poor"},
+		{"au-pm25-very-poor", StandardAUNEPM, v(40), none, 160, "Very Poor"},
+		{"au-pm25-hazardous", StandardAUNEPM, v(50), none, 200, "Hazardous"},
+		{"au-pm10-fair", StandardAUNEPM, v(5), v(40), 80, "Fair"},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.name, func(t *testing.T) {
+			standard, err := Lookup(tC.standard)
+			assert.NoError(t, err)
+			index, ok := standard.Index(tC.pm25, tC.pm10)
+			assert.True(t, ok)
+			assert.Equal(t, tC.value, index.Value)
+			assert.Equal(t, tC.category, index.Category.Name)
+		})
+	}
+}
+
+func TestStandardsWithoutReadings(t *testing.T) {
+	for _, name := range Names() {
+		standard, _ := Lookup(name)
+		_, ok := standard.Index(airgradient.Value{}, airgradient.Value{})
+		assert.False(t, ok, name)
+	}
+
+	// AQHI+ has no PM10 scale
+	standard, _ := Lookup(StandardCAAQHI)
+	_, ok := standard.Index(airgradient.Value{}, airgradient.NewValue(20))
+	assert.False(t, ok)
+}
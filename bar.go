@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/ljagiello/airdash/airgradient"
+	"github.com/ljagiello/airdash/aqi"
+	"github.com/ljagiello/airdash/bar"
+	"github.com/ljagiello/airdash/config"
+	"github.com/ljagiello/airdash/render"
+)
+
+// runBar implements the bar subcommand: it writes the menu bar title of the
+// configured locations to w in a status bar's protocol, every interval until
+// ctx is done, or once with -once. i3blocks runs the command again for every
+// update, so it needs -once. Failures are shown in the bar rather than
+// logged, as stdout belongs to the bar.
+func runBar(ctx context.Context, args []string, w io.Writer) error {
+	fs := flag.NewFlagSet("bar", flag.ContinueOnError)
+	configPath := fs.String("config", getDefaultConfigPath(), "path to config file")
+	format := fs.String("format", bar.Waybar, "bar protocol: "+strings.Join(bar.Formats, ", "))
+	once := fs.Bool("once", false, "write a single update and exit, as i3blocks expects")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	cfg, err := loadConfig(*configPath)
+	if err != nil {
+		return fmt.Errorf("loading config %s: %w", *configPath, err)
+	}
+	if cfg.Interval == 0 {
+		cfg.Interval = 60
+	}
+	if cfg.Aggregate == "" {
+		cfg.Aggregate = config.AggregateWorst
+	}
+
+	writer, err := bar.NewWriter(w, *format)
+	if err != nil {
+		return err
+	}
+	if *format == bar.I3blocks && !*once {
+		// Every update is several lines, which i3blocks only reads once per run
+		return errors.New("-format i3blocks writes a single update, add -once and set the block's interval")
+	}
+	source, err := newSource(cfg)
+	if err != nil {
+		return err
+	}
+	standard, err := aqi.Lookup(cfg.AQIStandard)
+	if err != nil {
+		return err
+	}
+	titleTemplate, err := render.ParseTitleTemplate(cfg.TitleTemplate)
+	if err != nil {
+		return err
+	}
+	levels, err := newLevels(cfg)
+	if err != nil {
+		return err
+	}
+
+	// The AQI NowCast follows the title's readings like in the menu bar
+	var tracker aqi.Tracker
+	update := func() error {
+		measures, err := source.Current(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return writer.Write(bar.ErrorStatus(err))
+		}
+		selected := airgradient.SelectLocations(measures, cfg.Locations)
+		if len(selected) == 0 {
+			return writer.Write(bar.ErrorStatus(airgradient.ErrNotFound))
+		}
+
+		opts := render.Options{Aggregate: cfg.Aggregate, TempUnit: cfg.TempUnit, AQI: cfg.AQI, Standard: standard, Comfort: cfg.Comfort, Title: titleTemplate, Levels: levels, MaxAge: cfg.StaleAfter()}
+		// Only locations that are online move the NowCast on
+		fresh := render.FreshMeasures(selected, opts)
+		if len(fresh) > 0 {
+			tracker.Add(render.AggregateMeasures(fresh, cfg.Aggregate))
+		}
+		if index, ok := tracker.Index(time.Now(), standard); ok {
+			opts.TitleAQI = &index
+		}
+		return writer.Write(bar.NewStatus(selected, opts))
+	}
+
+	if *once {
+		return update()
+	}
+	ticker := time.NewTicker(time.Duration(cfg.Interval) * time.Second)
+	defer ticker.Stop()
+	for {
+		// A write fails once the bar has closed the pipe
+		if err := update(); err != nil {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
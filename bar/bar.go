@@ -0,0 +1,205 @@
+// Package bar writes status updates in the protocols of status bars:
+// waybar, i3blocks, polybar, tmux and xbar.
+package bar
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+)
+
+// Formats.
+const (
+	// Waybar writes a JSON object per update for a custom module with
+	// "return-type": "json".
+	Waybar = "waybar"
+	// I3blocks writes the full text, short text and colour lines of a
+	// block.
+	I3blocks = "i3blocks"
+	// Polybar writes a line per update with a foreground colour tag, for a
+	// custom/script module with tail = true.
+	Polybar = "polybar"
+	// Tmux writes a line per update with a style, for #() in status-right.
+	Tmux = "tmux"
+	// Xbar writes the title line and a dropdown menu in the plugin format
+	// of xbar and SwiftBar, separated by ~~~ between updates as streaming
+	// plugins expect.
+	Xbar = "xbar"
+)
+
+// Formats lists every supported format.
+var Formats = []string{Waybar, I3blocks, Polybar, Tmux, Xbar}
+
+// ErrUnknownFormat is returned by NewWriter for an unsupported format.
+var ErrUnknownFormat = errors.New("unknown bar format")
+
+// Classes of the updates that are not classified by level.
+const (
+	// ClassError is the class of a failed update.
+	ClassError = "error"
+	// ClassStale is the class of readings older than the maximum age.
+	ClassStale = "stale"
+)
+
+// StaleColor is the colour of stale readings.
+const StaleColor = "#9E9E9E"
+
+// Status is one update of the bar.
+type Status struct {
+	// Text is the full text, e.g. the menu bar title.
+	Text string
+	// Short replaces Text where the bar is short of space, Text when empty.
+	Short string
+	// Tooltip lines are shown on hover, or in the xbar dropdown.
+	Tooltip []string
+	// Class names the status, e.g. "good" or ClassError, for styling.
+	Class string
+	// Color is the colour of the status as a hex RGB string, e.g.
+	// "#00E400", empty for the bar's default.
+	Color string
+	// Menu is the xbar dropdown, the Tooltip lines when empty.
+	Menu []MenuItem
+}
+
+// Separator is the Text of a MenuItem separating groups of items.
+const Separator = "---"
+
+// MenuItem is a line of the xbar dropdown.
+type MenuItem struct {
+	Text string
+	// Color is a hex RGB string, empty for the default.
+	Color string
+	// Href is opened when the item is clicked.
+	Href string
+	// Level nests the item in the submenu of the item above it.
+	Level int
+	// Refresh runs the plugin again when the item is clicked.
+	Refresh bool
+}
+
+// Writer writes statuses in a format.
+type Writer struct {
+	w       io.Writer
+	format  string
+	written bool
+}
+
+// NewWriter returns a writer of the format, or an error wrapping
+// ErrUnknownFormat.
+func NewWriter(w io.Writer, format string) (*Writer, error) {
+	if slices.Contains(Formats, format) {
+		return &Writer{w: w, format: format}, nil
+	}
+	return nil, fmt.Errorf("%w: %q, expected %s", ErrUnknownFormat, format, strings.Join(Formats, ", "))
+}
+
+// Write writes one update.
+func (bw *Writer) Write(s Status) error {
+	if s.Short == "" {
+		s.Short = s.Text
+	}
+	var err error
+	switch bw.format {
+	case Waybar:
+		err = bw.writeWaybar(s)
+	case I3blocks:
+		err = bw.writeI3blocks(s)
+	case Polybar:
+		err = bw.writePolybar(s)
+	case Tmux:
+		err = bw.writeTmux(s)
+	case Xbar:
+		err = bw.writeXbar(s)
+	}
+	bw.written = true
+	return err
+}
+
+func (bw *Writer) writeWaybar(s Status) error {
+	// waybar reads one JSON object per line
+	return json.NewEncoder(bw.w).Encode(struct {
+		Text    string `json:"text"`
+		Tooltip string `json:"tooltip,omitempty"`
+		Class   string `json:"class,omitempty"`
+	}{s.Text, strings.Join(s.Tooltip, "\n"), s.Class})
+}
+
+func (bw *Writer) writeI3blocks(s Status) error {
+	lines := []string{oneLine(s.Text), oneLine(s.Short)}
+	if s.Color != "" {
+		lines = append(lines, s.Color)
+	}
+	_, err := fmt.Fprintln(bw.w, strings.Join(lines, "\n"))
+	return err
+}
+
+func (bw *Writer) writePolybar(s Status) error {
+	text := oneLine(s.Text)
+	if s.Color != "" {
+		text = "%{F" + s.Color + "}" + text + "%{F-}"
+	}
+	_, err := fmt.Fprintln(bw.w, text)
+	return err
+}
+
+func (bw *Writer) writeTmux(s Status) error {
+	// # starts a format in the status line, ## is a literal one
+	text := strings.ReplaceAll(oneLine(s.Text), "#", "##")
+	if s.Color != "" {
+		text = "#[fg=" + s.Color + "]" + text + "#[default]"
+	}
+	_, err := fmt.Fprintln(bw.w, text)
+	return err
+}
+
+func (bw *Writer) writeXbar(s Status) error {
+	var b strings.Builder
+	if bw.written {
+		b.WriteString("~~~\n")
+	}
+	b.WriteString(xbarLine(MenuItem{Text: s.Text, Color: s.Color}))
+	menu := s.Menu
+	if len(menu) == 0 {
+		for _, line := range s.Tooltip {
+			menu = append(menu, MenuItem{Text: line})
+		}
+	}
+	if len(menu) > 0 {
+		b.WriteString(Separator + "\n")
+		for _, item := range menu {
+			b.WriteString(xbarLine(item))
+		}
+	}
+	_, err := io.WriteString(bw.w, b.String())
+	return err
+}
+
+// xbarLine formats a line of an xbar plugin, whose parameters follow a "|".
+func xbarLine(item MenuItem) string {
+	if item.Text == Separator {
+		return strings.Repeat("--", item.Level) + Separator + "\n"
+	}
+	var params []string
+	if item.Color != "" {
+		params = append(params, "color="+item.Color)
+	}
+	if item.Href != "" {
+		params = append(params, "href="+item.Href)
+	}
+	if item.Refresh {
+		params = append(params, "refresh=true")
+	}
+	line := strings.Repeat("--", item.Level) + strings.ReplaceAll(oneLine(item.Text), "|", "¦")
+	if len(params) > 0 {
+		line += " | " + strings.Join(params, " ")
+	}
+	return line + "\n"
+}
+
+// oneLine keeps line-based protocols in step when text has line breaks.
+func oneLine(text string) string {
+	return strings.ReplaceAll(text, "\n", " ")
+}
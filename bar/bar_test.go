@@ -0,0 +1,66 @@
+package bar
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testStatus = Status{
+	Text:    "🫧 548  #1",
+	Short:   "548",
+	Tooltip: []string{"AQI 17 · Good (PM2.5)", "Office | 2nd floor"},
+	Class:   "good",
+	Color:   "#00E400",
+}
+
+func TestWriter(t *testing.T) {
+	testCases := []struct {
+		format   string
+		status   Status
+		expected string
+	}{
+		{
+			Waybar,
+			testStatus,
+			`{"text":"🫧 548  #1","tooltip":"AQI 17 · Good (PM2.5)\nOffice | 2nd floor","class":"good"}` + "\n",
+		},
+		{
+			Waybar,
+			Status{Text: "⚠️ offline", Class: ClassError},
+			`{"text":"⚠️ offline","class":"error"}` + "\n",
+		},
+		{I3blocks, testStatus, "🫧 548  #1\n548\n#00E400\n"},
+		{I3blocks, Status{Text: "⚠️ offline"}, "⚠️ offline\n⚠️ offline\n"},
+		{Polybar, testStatus, "%{F#00E400}🫧 548  #1%{F-}\n"},
+		{Polybar, Status{Text: "⚠️ offline"}, "⚠️ offline\n"},
+		{Tmux, testStatus, "#[fg=#00E400]🫧 548  ##1#[default]\n"},
+		{Xbar, testStatus, "🫧 548  #1 | color=#00E400\n---\nAQI 17 · Good (PM2.5)\nOffice ¦ 2nd floor\n"},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.format, func(t *testing.T) {
+			var buf bytes.Buffer
+			w, err := NewWriter(&buf, tC.format)
+			require.NoError(t, err)
+			require.NoError(t, w.Write(tC.status))
+			assert.Equal(t, tC.expected, buf.String())
+		})
+	}
+}
+
+func TestWriterXbarStream(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, Xbar)
+	require.NoError(t, err)
+	require.NoError(t, w.Write(Status{Text: "548"}))
+	require.NoError(t, w.Write(Status{Text: "612"}))
+	assert.Equal(t, "548\n~~~\n612\n", buf.String())
+}
+
+func TestNewWriterUnknownFormat(t *testing.T) {
+	_, err := NewWriter(&bytes.Buffer{}, "dwm")
+	require.ErrorIs(t, err, ErrUnknownFormat)
+	assert.EqualError(t, err, `unknown bar format: "dwm", expected waybar, i3blocks, polybar, tmux, xbar`)
+}
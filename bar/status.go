@@ -0,0 +1,155 @@
+package bar
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ljagiello/airdash/airgradient"
+	"github.com/ljagiello/airdash/aqi"
+	"github.com/ljagiello/airdash/classify"
+	"github.com/ljagiello/airdash/render"
+)
+
+// DashboardURL is the AirGradient dashboard the menu links cloud locations
+// to.
+const DashboardURL = "https://app.airgradient.com/dashboard"
+
+// LocationURL returns the dashboard page of a cloud location.
+func LocationURL(locationID int) string {
+	return DashboardURL + "/location/" + strconv.Itoa(locationID)
+}
+
+// NewStatus builds the update of the selected measures: the menu bar title,
+// with the menu's lines as the tooltip and a menu of every location's
+// readings, classified by the worst level of any location that is not
+// stale, or as stale when every location is.
+func NewStatus(selected []airgradient.Measures, opts render.Options) Status {
+	if opts.Standard == nil {
+		opts.Standard = aqi.USEPA
+	}
+	if opts.Now.IsZero() {
+		opts.Now = time.Now()
+	}
+	view := render.BuildStatusView(selected, opts)
+	status := Status{Text: view.Title}
+	if len(selected) == 1 {
+		status.Tooltip = append(status.Tooltip, render.LocationLabel(selected[0]))
+	}
+	for _, line := range []string{view.AQI, view.Comfort} {
+		if line != "" {
+			status.Tooltip = append(status.Tooltip, line)
+		}
+	}
+	status.Tooltip = append(status.Tooltip, view.Locations...)
+
+	fresh := render.FreshMeasures(selected, opts)
+	if view.Stale {
+		status.Class = ClassStale
+		status.Color = StaleColor
+	} else if level := opts.Levels.Worst(fresh); level != classify.Unknown {
+		status.Class = level.String()
+		status.Color = level.Color()
+	}
+
+	if len(fresh) == 0 {
+		fresh = selected
+	}
+	agg := render.AggregateMeasures(fresh, opts.Aggregate)
+	if view.AQI != "" {
+		index, _ := opts.Standard.Index(agg.Pm02, agg.Pm10)
+		if opts.TitleAQI != nil {
+			index = *opts.TitleAQI
+		}
+		status.Menu = append(status.Menu, MenuItem{Text: view.AQI, Color: index.Category.Color}, MenuItem{Text: Separator})
+	}
+	for _, m := range selected {
+		status.Menu = append(status.Menu, locationMenu(m, opts, len(selected) > 1)...)
+	}
+	status.Menu = append(status.Menu, MenuItem{Text: Separator})
+	if !agg.Timestamp.IsZero() {
+		status.Menu = append(status.Menu, MenuItem{Text: "Updated " + render.FormatDuration(opts.Now.Sub(agg.Timestamp)) + " ago"})
+	}
+	status.Menu = append(status.Menu, MenuItem{Text: "Refresh", Refresh: true})
+	return status
+}
+
+// ErrorStatus builds the update of a failed fetch.
+func ErrorStatus(err error) Status {
+	return Status{
+		Text:    render.FormatErrorTitle(err),
+		Tooltip: []string{err.Error()},
+		Class:   ClassError,
+		Menu: []MenuItem{
+			{Text: err.Error()},
+			{Text: Separator},
+			{Text: "Refresh", Refresh: true},
+		},
+	}
+}
+
+// locationMenu lists the present readings of a location below its name,
+// in a submenu when there are several locations. The location and its
+// classified readings are coloured by their level, PM10 by its AQI category;
+// stale readings are left uncoloured below a grey location.
+func locationMenu(m airgradient.Measures, opts render.Options, nested bool) []MenuItem {
+	stale := render.IsStale(m, opts)
+	header := MenuItem{Text: render.LocationLabel(m), Color: opts.Levels.Measures(m).Color()}
+	if stale {
+		header.Text += " · no readings"
+		if !m.Timestamp.IsZero() {
+			header.Text += " for " + render.FormatDuration(opts.Now.Sub(m.Timestamp))
+		}
+		header.Color = StaleColor
+	}
+	// Monitors read through their local API have no dashboard page
+	if m.LocationID != 0 {
+		header.Href = LocationURL(m.LocationID)
+	}
+	items := []MenuItem{header}
+
+	level := 0
+	if nested {
+		level = 1
+	}
+	for _, metric := range render.ConvertMetrics(airgradient.Metrics, opts.TempUnit) {
+		v := metric.Value(m)
+		if !v.Valid {
+			continue
+		}
+		item := MenuItem{Text: metric.Label + ": " + formatReading(v.Float64, metric.Unit), Level: level}
+		switch {
+		case stale:
+		case metric.Name == "pm10":
+			item.Color = pm10Color(opts.Standard, v)
+		default:
+			item.Color = opts.Levels.Metric(metric.Name, m).Color()
+		}
+		items = append(items, item)
+	}
+	return items
+}
+
+// pm10Color is the colour of the AQI category of PM10 alone.
+func pm10Color(standard aqi.Standard, pm10 airgradient.Value) string {
+	index, ok := standard.Index(airgradient.Value{}, pm10)
+	if !ok {
+		return ""
+	}
+	return index.Category.Color
+}
+
+// formatReading formats a reading with at most one decimal and its unit.
+func formatReading(f float64, unit string) string {
+	text := strconv.FormatFloat(math.Round(f*10)/10, 'f', -1, 64)
+	switch {
+	case unit == "":
+		return text
+	case strings.HasPrefix(unit, "°"), unit == "%":
+		return text + unit
+	default:
+		return fmt.Sprintf("%s %s", text, unit)
+	}
+}
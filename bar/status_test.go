@@ -0,0 +1,140 @@
+package bar
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ljagiello/airdash/airgradient"
+	"github.com/ljagiello/airdash/aqi"
+	"github.com/ljagiello/airdash/classify"
+	"github.com/ljagiello/airdash/config"
+	"github.com/ljagiello/airdash/render"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var update = flag.Bool("update", false, "update the golden files in testdata")
+
+func TestXbarGolden(t *testing.T) {
+	v := airgradient.NewValue
+	now := time.Date(2023, 10, 10, 3, 47, 11, 0, time.UTC)
+	office := airgradient.Measures{
+		LocationID:   12345,
+		LocationName: "Office",
+		Pm01:         v(2),
+		Pm02:         v(4),
+		Pm10:         v(6),
+		Atmp:         v(24.3),
+		Rhum:         v(52),
+		Rco2:         v(548),
+		TvocIndex:    v(100),
+		Timestamp:    now.Add(-4 * time.Minute),
+	}
+	bedroom := airgradient.Measures{
+		LocationID:   23456,
+		LocationName: "Bedroom | 2nd floor",
+		Pm02:         v(38),
+		Atmp:         v(21),
+		Rco2:         v(1210),
+		Timestamp:    now.Add(-6 * time.Minute),
+	}
+	local := airgradient.Measures{
+		LocationName: "Living Room",
+		Pm02:         v(12.4),
+		Rco2:         v(447),
+		Timestamp:    now.Add(-time.Minute),
+	}
+
+	testCases := []struct {
+		name     string
+		measures []airgradient.Measures
+		opts     render.Options
+		err      error
+	}{
+		{
+			"single",
+			[]airgradient.Measures{office},
+			render.Options{TempUnit: "C", AQI: config.AQIAlongside, Now: now},
+			nil,
+		},
+		{
+			"multiple",
+			[]airgradient.Measures{office, bedroom},
+			render.Options{Aggregate: config.AggregateWorst, TempUnit: "F", Standard: aqi.USEPA, Now: now},
+			nil,
+		},
+		{
+			"local",
+			[]airgradient.Measures{local},
+			render.Options{Now: now},
+			nil,
+		},
+		{
+			"stale",
+			[]airgradient.Measures{office, bedroom},
+			render.Options{TempUnit: "C", Now: now.Add(2 * time.Hour), MaxAge: 10 * time.Minute},
+			nil,
+		},
+		{
+			"offline",
+			[]airgradient.Measures{office, bedroom},
+			render.Options{TempUnit: "C", Now: now, MaxAge: 5 * time.Minute},
+			nil,
+		},
+		{
+			"error",
+			nil,
+			render.Options{},
+			fmt.Errorf("%w: HTTP 401 from API", airgradient.ErrUnauthorized),
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.name, func(t *testing.T) {
+			status := NewStatus(tC.measures, tC.opts)
+			if tC.err != nil {
+				status = ErrorStatus(tC.err)
+			}
+			var buf bytes.Buffer
+			w, err := NewWriter(&buf, Xbar)
+			require.NoError(t, err)
+			require.NoError(t, w.Write(status))
+
+			golden := filepath.Join("testdata", "xbar-"+tC.name+".txt")
+			if *update {
+				require.NoError(t, os.WriteFile(golden, buf.Bytes(), 0o600))
+			}
+			expected, err := os.ReadFile(golden)
+			require.NoError(t, err)
+			assert.Equal(t, string(expected), buf.String())
+		})
+	}
+}
+
+func TestNewStatus(t *testing.T) {
+	v := airgradient.NewValue
+	measures := []airgradient.Measures{
+		{LocationID: 12345, LocationName: "Office", Pm02: v(4), Rco2: v(548)},
+		{LocationID: 23456, LocationName: "Bedroom", Pm02: v(38), Rco2: v(1210)},
+	}
+
+	status := NewStatus(measures[:1], render.Options{})
+	assert.Equal(t, "good", status.Class)
+	assert.Equal(t, classify.Good.Color(), status.Color)
+	assert.Equal(t, []string{"Office"}, status.Tooltip)
+
+	// The worst location wins
+	status = NewStatus(measures, render.Options{})
+	assert.Equal(t, "poor", status.Class)
+	assert.Equal(t, classify.Poor.Color(), status.Color)
+
+	// Configured bands replace the monitor's LED thresholds
+	levels, err := classify.New(map[string]classify.Bands{classify.CO2: {High: []float64{500, 1000, 2000}}}, "C")
+	require.NoError(t, err)
+	status = NewStatus(measures[:1], render.Options{Levels: levels})
+	assert.Equal(t, "moderate", status.Class)
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ljagiello/airdash/bar"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunBar(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.ServeFile(w, r, "airgradient/testdata/local-measures-current.json")
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "config.yaml")
+	require.NoError(t, os.WriteFile(configPath, []byte("source: local\ndevice:\n  host: "+server.URL+"\n  name: Office\n"), 0o600))
+	templatePath := filepath.Join(dir, "template.yaml")
+	require.NoError(t, os.WriteFile(templatePath, []byte("source: local\ndevice:\n  host: "+server.URL+"\ntitleTemplate: 'CO2 {{round 0 .Rco2}}'\n"), 0o600))
+
+	levelsPath := filepath.Join(dir, "levels.yaml")
+	require.NoError(t, os.WriteFile(levelsPath, []byte("source: local\ndevice:\n  host: "+server.URL+"\nlevels:\n  rco2:\n    high: [400, 1000]\n"), 0o600))
+
+	testCases := []struct {
+		name     string
+		args     []string
+		expected string
+	}{
+		{
+			"waybar",
+			[]string{"-config", configPath},
+			`{"text":"🌡️ 24.36  💨 2  💧 55.2  🫧 447","tooltip":"Office","class":"good"}` + "\n",
+		},
+		{
+			"i3blocks",
+			[]string{"-config", configPath, "-format", bar.I3blocks},
+			"🌡️ 24.36  💨 2  💧 55.2  🫧 447\n🌡️ 24.36  💨 2  💧 55.2  🫧 447\n#00E400\n",
+		},
+		{
+			"tmux-template",
+			[]string{"-config", templatePath, "-format", bar.Tmux},
+			"#[fg=#00E400]CO2 447#[default]\n",
+		},
+		{
+			"levels",
+			[]string{"-config", levelsPath, "-format", bar.Polybar},
+			"%{F#FFFF00}🌡️ 24.36  💨 2  💧 55.2  🫧 447%{F-}\n",
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			require.NoError(t, runBar(context.Background(), append(tC.args, "-once"), &buf))
+			assert.Equal(t, tC.expected, buf.String())
+		})
+	}
+}
+
+func TestRunBarError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(configPath, []byte("source: local\ndevice:\n  host: "+server.URL+"\n"), 0o600))
+
+	var buf bytes.Buffer
+	require.NoError(t, runBar(context.Background(), []string{"-config", configPath, "-format", bar.Polybar, "-once"}, &buf))
+	assert.Equal(t, "⚠️ API unavailable\n", buf.String())
+}
+
+func TestRunBarI3blocksNeedsOnce(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(configPath, []byte("token: SECRET-TOKEN\n"), 0o600))
+
+	var buf bytes.Buffer
+	err := runBar(context.Background(), []string{"-config", configPath, "-format", bar.I3blocks}, &buf)
+	require.ErrorContains(t, err, "add -once")
+	assert.Empty(t, buf.String())
+}
+
+func TestRunBarUnknownFormat(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(configPath, []byte("token: SECRET-TOKEN\n"), 0o600))
+
+	err := runBar(context.Background(), []string{"-config", configPath, "-format", "dwm"}, &bytes.Buffer{})
+	require.ErrorIs(t, err, bar.ErrUnknownFormat)
+}
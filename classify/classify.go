@@ -0,0 +1,233 @@
+// Package classify maps readings to good, moderate, poor and hazardous
+// levels, for colouring the menu bar title and every other output alike.
+package classify
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/ljagiello/airdash/airgradient"
+)
+
+// Level is how good a reading is. Higher is worse.
+type Level int
+
+// Levels.
+const (
+	// Unknown is the level of a missing or unclassified reading.
+	Unknown Level = iota
+	Good
+	Moderate
+	Poor
+	Hazardous
+)
+
+var levelNames = []string{"unknown", "good", "moderate", "poor", "hazardous"}
+
+// String returns the level's name, e.g. "moderate".
+func (l Level) String() string {
+	if l < Unknown || int(l) >= len(levelNames) {
+		return fmt.Sprintf("Level(%d)", int(l))
+	}
+	return levelNames[l]
+}
+
+// Color returns the level's colour as a hex RGB string, following the
+// monitor's LEDs, or "" for Unknown.
+func (l Level) Color() string {
+	switch l {
+	case Good:
+		return "#00E400"
+	case Moderate:
+		return "#FFFF00"
+	case Poor:
+		return "#FF7E00"
+	case Hazardous:
+		return "#FF0000"
+	default:
+		return ""
+	}
+}
+
+// Bands are the edges between the levels of a metric, as configured in
+// config.yaml:
+//
+//	levels:
+//	  rco2:
+//	    high: [800, 1200, 2000]
+//	  rhum:
+//	    low: [30, 25, 20]
+//	    high: [60, 70, 80]
+//
+// A reading is moderate from High[0], poor from High[1] and hazardous from
+// High[2]. Low works the same way downwards, for metrics that are also bad
+// when too low: moderate at or below Low[0], and so on. Either list may
+// have fewer than three edges, leaving out the worse levels.
+type Bands struct {
+	Low  []float64 `yaml:"low"`
+	High []float64 `yaml:"high"`
+}
+
+// Level classifies a reading.
+func (b Bands) Level(v float64) Level {
+	level := Good
+	for i, edge := range b.High {
+		if v >= edge {
+			level = Good + Level(i+1)
+		}
+	}
+	for i, edge := range b.Low {
+		if v <= edge {
+			level = max(level, Good+Level(i+1))
+		}
+	}
+	return level
+}
+
+// validate checks the edges are in order and the low ones below the high
+// ones.
+func (b Bands) validate() error {
+	if len(b.Low) == 0 && len(b.High) == 0 {
+		return errors.New("no edges")
+	}
+	if len(b.Low) > 3 || len(b.High) > 3 {
+		return errors.New("at most 3 low and 3 high edges")
+	}
+	for i := 1; i < len(b.High); i++ {
+		if b.High[i] <= b.High[i-1] {
+			return fmt.Errorf("high edges %v must increase", b.High)
+		}
+	}
+	for i := 1; i < len(b.Low); i++ {
+		if b.Low[i] >= b.Low[i-1] {
+			return fmt.Errorf("low edges %v must decrease", b.Low)
+		}
+	}
+	if len(b.Low) > 0 && len(b.High) > 0 && b.Low[0] >= b.High[0] {
+		return fmt.Errorf("low edge %v must be below high edge %v", b.Low[0], b.High[0])
+	}
+	return nil
+}
+
+// Classified metrics.
+const (
+	CO2         = "rco2"
+	PM25        = "pm02"
+	TVOCIndex   = "tvocIndex"
+	NOxIndex    = "noxIndex"
+	Humidity    = "rhum"
+	Temperature = "atmp"
+)
+
+// Metrics lists the classified metrics.
+var Metrics = []string{CO2, PM25, TVOCIndex, NOxIndex, Humidity, Temperature}
+
+// DefaultBands are the bands of every classified metric but CO2, which
+// follows the LED thresholds the monitor reports. Temperatures are in °C.
+var DefaultBands = map[string]Bands{
+	// The US EPA AQI categories from Moderate to Unhealthy
+	PM25: {High: []float64{9.1, 35.5, 55.5}},
+	// Sensirion's guidance for its VOC and NOx indices
+	TVOCIndex: {High: []float64{150, 250, 400}},
+	NOxIndex:  {High: []float64{20, 150, 300}},
+	Humidity:  {Low: []float64{30, 25, 20}, High: []float64{60, 70, 80}},
+	// Indoor comfort
+	Temperature: {Low: []float64{18, 16, 12}, High: []float64{26, 28, 32}},
+}
+
+// DefaultCO2Bands classify CO2 when the monitor reports no LED thresholds,
+// the AirGradient defaults.
+var DefaultCO2Bands = Bands{High: []float64{1000, 2000, 4000}}
+
+// ErrInvalidBands is returned by New for bands it cannot classify with.
+var ErrInvalidBands = errors.New("invalid level bands")
+
+// Classifier classifies readings with the configured bands, falling back to
+// the defaults. A nil Classifier uses the defaults only.
+type Classifier struct {
+	bands map[string]Bands
+}
+
+// New returns a classifier of the configured bands, whose temperature edges
+// are in tempUnit, "C" or "F". It returns an error wrapping
+// ErrInvalidBands for an unknown metric or edges out of order.
+func New(bands map[string]Bands, tempUnit string) (*Classifier, error) {
+	c := &Classifier{bands: make(map[string]Bands, len(bands))}
+	for metric, b := range bands {
+		if !slices.Contains(Metrics, metric) {
+			return nil, fmt.Errorf("%w: unknown metric %q, expected one of %s",
+				ErrInvalidBands, metric, strings.Join(Metrics, ", "))
+		}
+		if err := b.validate(); err != nil {
+			return nil, fmt.Errorf("%w: %s: %w", ErrInvalidBands, metric, err)
+		}
+		if metric == Temperature && tempUnit == "F" {
+			b = Bands{Low: toCelsius(b.Low), High: toCelsius(b.High)}
+		}
+		c.bands[metric] = b
+	}
+	return c, nil
+}
+
+// Bands returns the bands a metric of m is classified with, and false for
+// a metric that is not classified.
+func (c *Classifier) Bands(metric string, m airgradient.Measures) (Bands, bool) {
+	if c != nil {
+		if b, ok := c.bands[metric]; ok {
+			return b, true
+		}
+	}
+	if metric == CO2 {
+		if m.LedCo2Threshold1 > 0 && m.LedCo2Threshold2 > m.LedCo2Threshold1 && m.LedCo2ThresholdEnd > m.LedCo2Threshold2 {
+			return Bands{High: []float64{m.LedCo2Threshold1, m.LedCo2Threshold2, m.LedCo2ThresholdEnd}}, true
+		}
+		return DefaultCO2Bands, true
+	}
+	b, ok := DefaultBands[metric]
+	return b, ok
+}
+
+// Metric returns the level of a metric of m, Unknown when it is missing or
+// not classified.
+func (c *Classifier) Metric(metric string, m airgradient.Measures) Level {
+	b, ok := c.Bands(metric, m)
+	if !ok {
+		return Unknown
+	}
+	for _, known := range airgradient.Metrics {
+		if known.Name == metric {
+			if v := known.Value(m); v.Valid {
+				return b.Level(v.Float64)
+			}
+		}
+	}
+	return Unknown
+}
+
+// Measures returns the worst level of the classified metrics of m.
+func (c *Classifier) Measures(m airgradient.Measures) Level {
+	worst := Unknown
+	for _, metric := range Metrics {
+		worst = max(worst, c.Metric(metric, m))
+	}
+	return worst
+}
+
+// Worst returns the worst level of the classified metrics of all measures.
+func (c *Classifier) Worst(measures []airgradient.Measures) Level {
+	worst := Unknown
+	for _, m := range measures {
+		worst = max(worst, c.Measures(m))
+	}
+	return worst
+}
+
+func toCelsius(edges []float64) []float64 {
+	celsius := make([]float64, len(edges))
+	for i, f := range edges {
+		celsius[i] = (f - 32) * 5 / 9
+	}
+	return celsius
+}
@@ -0,0 +1,114 @@
+package classify
+
+import (
+	"testing"
+
+	"github.com/ljagiello/airdash/airgradient"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBandsLevel(t *testing.T) {
+	humidity := DefaultBands[Humidity]
+	testCases := []struct {
+		name     string
+		bands    Bands
+		value    float64
+		expected Level
+	}{
+		{"below-first-edge", DefaultCO2Bands, 999, Good},
+		{"at-first-edge", DefaultCO2Bands, 1000, Moderate},
+		{"poor", DefaultCO2Bands, 2500, Poor},
+		{"hazardous", DefaultCO2Bands, 4000, Hazardous},
+		{"comfortable", humidity, 45, Good},
+		{"dry", humidity, 28, Moderate},
+		{"very-dry", humidity, 18, Hazardous},
+		{"humid", humidity, 72, Poor},
+		{"fewer-edges", Bands{High: []float64{10}}, 1000, Moderate},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.name, func(t *testing.T) {
+			assert.Equal(t, tC.expected, tC.bands.Level(tC.value))
+		})
+	}
+}
+
+func TestClassifier(t *testing.T) {
+	v := airgradient.NewValue
+	led := airgradient.Measures{
+		Rco2:               v(900),
+		LedCo2Threshold1:   800,
+		LedCo2Threshold2:   1000,
+		LedCo2ThresholdEnd: 1500,
+	}
+	custom, err := New(map[string]Bands{
+		CO2:         {High: []float64{500}},
+		Temperature: {Low: []float64{64.4}, High: []float64{78.8}},
+	}, "F")
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name       string
+		classifier *Classifier
+		metric     string
+		measures   airgradient.Measures
+		expected   Level
+	}{
+		{"led-thresholds", nil, CO2, led, Moderate},
+		{"default-co2", nil, CO2, airgradient.Measures{Rco2: v(900)}, Good},
+		{"configured-co2", custom, CO2, led, Moderate},
+		{"configured-co2-poor", custom, CO2, airgradient.Measures{Rco2: v(1500)}, Moderate},
+		{"fahrenheit-edges", custom, Temperature, airgradient.Measures{Atmp: v(17)}, Moderate},
+		{"fahrenheit-edges-good", custom, Temperature, airgradient.Measures{Atmp: v(22)}, Good},
+		{"default-pm25", custom, PM25, airgradient.Measures{Pm02: v(40)}, Poor},
+		{"missing", nil, PM25, airgradient.Measures{}, Unknown},
+		{"not-classified", nil, "wifi", airgradient.Measures{Wifi: v(-90)}, Unknown},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.name, func(t *testing.T) {
+			assert.Equal(t, tC.expected, tC.classifier.Metric(tC.metric, tC.measures))
+		})
+	}
+}
+
+func TestClassifierWorst(t *testing.T) {
+	v := airgradient.NewValue
+	var c *Classifier
+	measures := []airgradient.Measures{
+		{Rco2: v(548), Pm02: v(4), Rhum: v(45)},
+		{Rco2: v(1210), TvocIndex: v(420)},
+	}
+	assert.Equal(t, Good, c.Measures(measures[0]))
+	assert.Equal(t, Hazardous, c.Measures(measures[1]))
+	assert.Equal(t, Hazardous, c.Worst(measures))
+	assert.Equal(t, Unknown, c.Worst(nil))
+}
+
+func TestNewInvalid(t *testing.T) {
+	testCases := []struct {
+		name  string
+		bands map[string]Bands
+		err   string
+	}{
+		{"unknown-metric", map[string]Bands{"co2": {High: []float64{800}}}, `unknown metric "co2", expected one of rco2, pm02, tvocIndex, noxIndex, rhum, atmp`},
+		{"no-edges", map[string]Bands{CO2: {}}, "rco2: no edges"},
+		{"too-many", map[string]Bands{CO2: {High: []float64{1, 2, 3, 4}}}, "at most 3"},
+		{"high-order", map[string]Bands{CO2: {High: []float64{1000, 800}}}, "high edges [1000 800] must increase"},
+		{"low-order", map[string]Bands{Humidity: {Low: []float64{20, 30}}}, "low edges [20 30] must decrease"},
+		{"overlap", map[string]Bands{Humidity: {Low: []float64{60}, High: []float64{50}}}, "low edge 60 must be below high edge 50"},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.name, func(t *testing.T) {
+			_, err := New(tC.bands, "C")
+			require.ErrorIs(t, err, ErrInvalidBands)
+			assert.ErrorContains(t, err, tC.err)
+		})
+	}
+}
+
+func TestLevel(t *testing.T) {
+	assert.Equal(t, "poor", Poor.String())
+	assert.Equal(t, "#FF7E00", Poor.Color())
+	assert.Empty(t, Unknown.Color())
+	assert.Equal(t, "Level(7)", Level(7).String())
+}
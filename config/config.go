@@ -0,0 +1,294 @@
+// Package config loads the AirDash configuration file.
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/ljagiello/airdash/airgradient"
+	"github.com/ljagiello/airdash/aqi"
+	"gopkg.in/yaml.v3"
+)
+
+// Data sources.
+const (
+	// SourceCloud reads from the AirGradient public API (the default).
+	SourceCloud = "cloud"
+	// SourceLocal reads from a monitor's local HTTP API.
+	SourceLocal = "local"
+)
+
+// Ways to combine several locations into the title, see
+// render.AggregateMeasures.
+const (
+	// AggregateWorst shows the highest pollutant readings (the default).
+	AggregateWorst = "worst"
+	// AggregateMean shows the average readings.
+	AggregateMean = "mean"
+)
+
+// Ways to show the AQI in the title.
+const (
+	// AQIOff shows the raw PM2.5 concentration (the default).
+	AQIOff = "off"
+	// AQIInstead shows the AQI in place of the PM2.5 concentration.
+	AQIInstead = "instead"
+	// AQIAlongside shows the AQI next to the PM2.5 concentration.
+	AQIAlongside = "alongside"
+)
+
+// Config is the AirDash configuration.
+type Config struct {
+	Token       string   `yaml:"token"`
+	LocationID  int      `yaml:"locationId"`
+	Locations   []string `yaml:"locations"`
+	Aggregate   string   `yaml:"aggregate"`
+	Interval    int      `yaml:"interval"`
+	TempUnit    string   `yaml:"tempUnit"`
+	Source      string   `yaml:"source"`
+	Device      Device   `yaml:"device"`
+	AQI         string   `yaml:"aqi"`
+	AQIStandard string   `yaml:"aqiStandard"`
+	// PM25Correction is airgradient.CorrectionNone (the default) or
+	// airgradient.CorrectionEPA.
+	PM25Correction string `yaml:"pm25Correction"`
+	// MaxAge is how old readings can be before they are shown as stale and
+	// their location is reported offline, see StaleAfter.
+	MaxAge time.Duration `yaml:"maxAge"`
+	// Comfort adds dew point, heat index and other derived metrics to the
+	// menu.
+	Comfort bool `yaml:"comfort"`
+	// TitleTemplate replaces the built-in menu bar title, see
+	// render.TitleTemplate.
+	TitleTemplate string `yaml:"titleTemplate"`
+	// Levels overrides the bands readings are classified good, moderate,
+	// poor or hazardous with, keyed by metric.
+	Levels map[string]Bands `yaml:"levels"`
+	// Alerts are evaluated on every update.
+	Alerts []Alert `yaml:"alerts"`
+	// Webhooks receive every alert event.
+	Webhooks []Webhook `yaml:"webhooks"`
+	// Metrics serves the measures to Prometheus.
+	Metrics Metrics `yaml:"metrics"`
+	// MQTT publishes every update to a broker when its broker is set.
+	MQTT MQTT `yaml:"mqtt"`
+	// InfluxDB receives every update when its URL is set.
+	InfluxDB InfluxDB `yaml:"influxdb"`
+	// History keeps every update in a local database when enabled.
+	History History `yaml:"history"`
+}
+
+// StaleAfter returns how old readings can be before they are stale: MaxAge,
+// or five update intervals but at least 10 minutes when it is not set.
+func (c *Config) StaleAfter() time.Duration {
+	if c.MaxAge > 0 {
+		return c.MaxAge
+	}
+	interval := time.Duration(c.Interval) * time.Second
+	if interval <= 0 {
+		interval = time.Minute
+	}
+	return max(5*interval, 10*time.Minute)
+}
+
+// Bands are the edges between the levels of a metric, see classify.Bands.
+type Bands struct {
+	Low  []float64 `yaml:"low"`
+	High []float64 `yaml:"high"`
+}
+
+// Alert is an alert rule, see alert.Rule.
+type Alert struct {
+	Name       string        `yaml:"name"`
+	Metric     string        `yaml:"metric"`
+	Comparator string        `yaml:"comparator"`
+	Threshold  float64       `yaml:"threshold"`
+	Clear      *float64      `yaml:"clear"`
+	For        time.Duration `yaml:"for"`
+	Cooldown   time.Duration `yaml:"cooldown"`
+	Locations  []string      `yaml:"locations"`
+}
+
+// Webhook receives alert events, see notify.WebhookConfig.
+type Webhook struct {
+	URL      string            `yaml:"url"`
+	Preset   string            `yaml:"preset"`
+	Template string            `yaml:"template"`
+	Secret   string            `yaml:"secret"`
+	Timeout  time.Duration     `yaml:"timeout"`
+	Headers  map[string]string `yaml:"headers"`
+}
+
+// MQTT is the broker every update is published to, see mqtt.Config.
+type MQTT struct {
+	Broker        string        `yaml:"broker"`
+	ClientID      string        `yaml:"clientId"`
+	Username      string        `yaml:"username"`
+	Password      string        `yaml:"password"`
+	TopicPrefix   string        `yaml:"topicPrefix"`
+	QoS           byte          `yaml:"qos"`
+	Retain        bool          `yaml:"retain"`
+	Timeout       time.Duration `yaml:"timeout"`
+	TLS           MQTTTLS       `yaml:"tls"`
+	HomeAssistant HomeAssistant `yaml:"homeAssistant"`
+}
+
+// MQTTTLS configures the connection to a TLS broker, see mqtt.TLS.
+type MQTTTLS struct {
+	CAFile             string `yaml:"caFile"`
+	CertFile           string `yaml:"certFile"`
+	KeyFile            string `yaml:"keyFile"`
+	InsecureSkipVerify bool   `yaml:"insecureSkipVerify"`
+}
+
+// HomeAssistant configures MQTT discovery, see mqtt.HomeAssistant.
+type HomeAssistant struct {
+	Discovery bool   `yaml:"discovery"`
+	Prefix    string `yaml:"prefix"`
+}
+
+// InfluxDB is the database every update is written to, see influx.Config.
+type InfluxDB struct {
+	URL       string        `yaml:"url"`
+	Token     string        `yaml:"token"`
+	Org       string        `yaml:"org"`
+	Bucket    string        `yaml:"bucket"`
+	BatchSize int           `yaml:"batchSize"`
+	MaxBuffer int           `yaml:"maxBuffer"`
+	Timeout   time.Duration `yaml:"timeout"`
+}
+
+// History is the local history store, see store.Config.
+type History struct {
+	// Enabled turns the store on.
+	Enabled bool `yaml:"enabled"`
+	// Path is the database file, ~/.airdash/history.db by default. A
+	// leading "~/" is the home directory.
+	Path   string        `yaml:"path"`
+	Raw    time.Duration `yaml:"raw"`
+	Hourly time.Duration `yaml:"hourly"`
+}
+
+// Metrics configures the Prometheus exporter.
+type Metrics struct {
+	// Listen is the address /metrics is served on, e.g. ":9101". The menu
+	// bar app only serves metrics when it is set.
+	Listen string `yaml:"listen"`
+}
+
+// Device is a monitor read through its local HTTP API.
+type Device struct {
+	// Host is the monitor's host name, e.g. "airgradient_<serial>.local",
+	// or the base URL of its local API.
+	Host string `yaml:"host"`
+	// Name is shown as the location name, defaulting to the serial number.
+	Name string `yaml:"name"`
+}
+
+// LoadConfig loads the config from the given path.
+func LoadConfig(path string) (*Config, error) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.ReadFile(absPath)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := new(Config)
+	if err := yaml.Unmarshal(f, &cfg); err != nil {
+		return nil, err
+	}
+	if cfg.MaxAge < 0 {
+		return nil, fmt.Errorf("invalid maxAge %v: cannot be negative", cfg.MaxAge)
+	}
+	switch cfg.Aggregate {
+	case "", AggregateWorst, AggregateMean:
+	default:
+		return nil, fmt.Errorf("invalid aggregate %q, expected %q or %q", cfg.Aggregate, AggregateWorst, AggregateMean)
+	}
+	switch cfg.Source {
+	case "", SourceCloud, SourceLocal:
+	default:
+		return nil, fmt.Errorf("invalid source %q, expected %q or %q", cfg.Source, SourceCloud, SourceLocal)
+	}
+	switch cfg.AQI {
+	case "", AQIOff, AQIInstead, AQIAlongside:
+	default:
+		return nil, fmt.Errorf("invalid aqi %q, expected %q, %q or %q", cfg.AQI, AQIOff, AQIInstead, AQIAlongside)
+	}
+	if _, err := aqi.Lookup(cfg.AQIStandard); err != nil {
+		return nil, fmt.Errorf("invalid aqiStandard: %w", err)
+	}
+	switch cfg.PM25Correction {
+	case "", airgradient.CorrectionNone, airgradient.CorrectionEPA:
+	default:
+		return nil, fmt.Errorf("invalid pm25Correction %q, expected %q or %q", cfg.PM25Correction, airgradient.CorrectionNone, airgradient.CorrectionEPA)
+	}
+
+	return cfg, nil
+}
+
+// SetLocalDevice switches the config file at path to read from the given
+// monitor, creating the file if needed. Other settings and comments are kept.
+func SetLocalDevice(path string, device Device) error {
+	var doc yaml.Node
+	f, err := os.ReadFile(path)
+	switch {
+	case err == nil:
+		if err := yaml.Unmarshal(f, &doc); err != nil {
+			return err
+		}
+	case !os.IsNotExist(err):
+		return err
+	}
+
+	if doc.Kind == 0 {
+		doc = yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode}}}
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return fmt.Errorf("config %s is not a mapping", path)
+	}
+
+	deviceNode := &yaml.Node{Kind: yaml.MappingNode}
+	setMappingValue(deviceNode, "host", &yaml.Node{Kind: yaml.ScalarNode, Value: device.Host})
+	if device.Name != "" {
+		setMappingValue(deviceNode, "name", &yaml.Node{Kind: yaml.ScalarNode, Value: device.Name})
+	}
+	setMappingValue(root, "source", &yaml.Node{Kind: yaml.ScalarNode, Value: SourceLocal})
+	setMappingValue(root, "device", deviceNode)
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(&doc); err != nil {
+		return err
+	}
+	if err := enc.Close(); err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	return os.WriteFile(path, buf.Bytes(), 0o600)
+}
+
+// setMappingValue sets key in a YAML mapping node, replacing an existing
+// value in place so its position and comments are kept.
+func setMappingValue(mapping *yaml.Node, key string, value *yaml.Node) {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			value.HeadComment = mapping.Content[i+1].HeadComment
+			value.LineComment = mapping.Content[i+1].LineComment
+			mapping.Content[i+1] = value
+			return
+		}
+	}
+	mapping.Content = append(mapping.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: key}, value)
+}
@@ -0,0 +1,244 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func CreateTestConfig(t *testing.T, configContent []byte) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yml")
+	require.NoError(t, os.WriteFile(path, configContent, 0o600))
+	return path
+}
+
+func TestLoadConfig(t *testing.T) {
+	testCases := []struct {
+		name          string
+		configContent []byte
+		token         string
+		locationID    int
+		interval      int
+		tempUnit      string
+		err           error
+	}{
+		{
+			"full-token",
+			[]byte("token: \"1234567890\"\nlocationId: 0\ninterval: 60\ntempUnit: \"C\""),
+			"1234567890",
+			0,
+			60,
+			"C",
+			nil,
+		},
+		{
+			"missing-interval",
+			[]byte("token: \"1234567890\"\ntempUnit: \"F\""),
+			"1234567890",
+			0,
+			0,
+			"F",
+			nil,
+		},
+		{
+			"invalid-config",
+			[]byte(`foobar-invalid`),
+			"",
+			0,
+			0,
+			"",
+			&yaml.TypeError{Errors: []string{"line 1: cannot unmarshal !!str `foobar-...` into config.Config"}},
+		},
+	}
+
+	for _, tC := range testCases {
+		t.Run(tC.name, func(t *testing.T) {
+			configPath := CreateTestConfig(t, tC.configContent)
+
+			cfg, err := LoadConfig(configPath)
+			if err == nil {
+				assert.Equal(t, tC.token, cfg.Token)
+				assert.Equal(t, tC.locationID, cfg.LocationID)
+				assert.Equal(t, tC.interval, cfg.Interval)
+				assert.Equal(t, tC.tempUnit, cfg.TempUnit)
+			}
+			assert.Equal(t, tC.err, err)
+		})
+	}
+}
+
+func TestLoadConfigLocations(t *testing.T) {
+	configPath := CreateTestConfig(t, []byte("token: \"1234567890\"\nlocations:\n  - 12345\n  - Meeting Room\naggregate: mean"))
+
+	cfg, err := LoadConfig(configPath)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"12345", "Meeting Room"}, cfg.Locations)
+	assert.Equal(t, "mean", cfg.Aggregate)
+}
+
+func TestLoadConfigInvalid(t *testing.T) {
+	testCases := []struct {
+		name   string
+		config string
+		err    string
+	}{
+		{"aggregate", "aggregate: max", `invalid aggregate "max", expected "worst" or "mean"`},
+		{"source", "source: lan", `invalid source "lan", expected "cloud" or "local"`},
+		{"aqi", "aqi: on", `invalid aqi "on", expected "off", "instead" or "alongside"`},
+		{"aqi-standard", "aqiStandard: us", `invalid aqiStandard: unknown AQI standard "us", expected one of [au-nepm`},
+		{"pm25-correction", "pm25Correction: device", `invalid pm25Correction "device", expected "none" or "epa"`},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.name, func(t *testing.T) {
+			_, err := LoadConfig(CreateTestConfig(t, []byte(tC.config)))
+			require.ErrorContains(t, err, tC.err)
+		})
+	}
+}
+
+func TestLoadConfigLocalDevice(t *testing.T) {
+	configPath := CreateTestConfig(t, []byte("source: local\ndevice:\n  host: airgradient_84fce612f5b8.local\n  name: Living Room"))
+
+	cfg, err := LoadConfig(configPath)
+	require.NoError(t, err)
+	assert.Equal(t, SourceLocal, cfg.Source)
+	assert.Equal(t, Device{Host: "airgradient_84fce612f5b8.local", Name: "Living Room"}, cfg.Device)
+}
+
+func TestLoadConfigAlerts(t *testing.T) {
+	configPath := CreateTestConfig(t, []byte(`alerts:
+  - name: CO2 high
+    metric: rco2
+    comparator: ">"
+    threshold: 1000
+    clear: 800
+    for: 5m
+    cooldown: 1h
+    locations: [Meeting Room]
+`))
+
+	cfg, err := LoadConfig(configPath)
+	require.NoError(t, err)
+	clearAt := 800.0
+	assert.Equal(t, []Alert{{
+		Name:       "CO2 high",
+		Metric:     "rco2",
+		Comparator: ">",
+		Threshold:  1000,
+		Clear:      &clearAt,
+		For:        5 * time.Minute,
+		Cooldown:   time.Hour,
+		Locations:  []string{"Meeting Room"},
+	}}, cfg.Alerts)
+}
+
+func TestLoadConfigLevels(t *testing.T) {
+	configPath := CreateTestConfig(t, []byte("levels:\n  rco2:\n    high: [800, 1200, 2000]\n  rhum:\n    low: [30]\n    high: [60]\n"))
+
+	cfg, err := LoadConfig(configPath)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]Bands{
+		"rco2": {High: []float64{800, 1200, 2000}},
+		"rhum": {Low: []float64{30}, High: []float64{60}},
+	}, cfg.Levels)
+}
+
+func TestStaleAfter(t *testing.T) {
+	testCases := []struct {
+		name     string
+		config   string
+		expected time.Duration
+	}{
+		{"default", "", 10 * time.Minute},
+		{"long-interval", "interval: 300", 25 * time.Minute},
+		{"configured", "interval: 300\nmaxAge: 1h", time.Hour},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.name, func(t *testing.T) {
+			cfg, err := LoadConfig(CreateTestConfig(t, []byte(tC.config)))
+			require.NoError(t, err)
+			assert.Equal(t, tC.expected, cfg.StaleAfter())
+		})
+	}
+
+	_, err := LoadConfig(CreateTestConfig(t, []byte("maxAge: -5m")))
+	require.ErrorContains(t, err, "invalid maxAge -5m0s")
+}
+
+func TestLoadConfigWebhooks(t *testing.T) {
+	configPath := CreateTestConfig(t, []byte(`webhooks:
+  - url: https://hooks.slack.com/services/T000/B000/XXXX
+    preset: slack
+  - url: https://example.com/airdash
+    secret: s3cret
+    timeout: 5s
+    headers:
+      Authorization: Bearer abc
+`))
+
+	cfg, err := LoadConfig(configPath)
+	require.NoError(t, err)
+	assert.Equal(t, []Webhook{
+		{URL: "https://hooks.slack.com/services/T000/B000/XXXX", Preset: "slack"},
+		{
+			URL:     "https://example.com/airdash",
+			Secret:  "s3cret",
+			Timeout: 5 * time.Second,
+			Headers: map[string]string{"Authorization": "Bearer abc"},
+		},
+	}, cfg.Webhooks)
+}
+
+func TestSetLocalDevice(t *testing.T) {
+	testCases := []struct {
+		name     string
+		existing []byte
+		device   Device
+		expected string
+	}{
+		{
+			"new-file",
+			nil,
+			Device{Host: "airgradient_84fce612f5b8.local"},
+			"source: local\ndevice:\n  host: airgradient_84fce612f5b8.local\n",
+		},
+		{
+			"keeps-settings-and-comments",
+			[]byte("# API token\ntoken: \"1234567890\"\ninterval: 60 # seconds\n"),
+			Device{Host: "airgradient_84fce612f5b8.local", Name: "Living Room"},
+			"# API token\ntoken: \"1234567890\"\ninterval: 60 # seconds\nsource: local\ndevice:\n  host: airgradient_84fce612f5b8.local\n  name: Living Room\n",
+		},
+		{
+			"replaces-device",
+			[]byte("source: cloud\ndevice:\n  host: old.local\n  name: Old\ntempUnit: F\n"),
+			Device{Host: "http://192.168.1.20"},
+			"source: local\ndevice:\n  host: http://192.168.1.20\ntempUnit: F\n",
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "airdash", "config.yaml")
+			if tC.existing != nil {
+				require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o700))
+				require.NoError(t, os.WriteFile(path, tC.existing, 0o600))
+			}
+
+			require.NoError(t, SetLocalDevice(path, tC.device))
+
+			content, err := os.ReadFile(path)
+			require.NoError(t, err)
+			assert.Equal(t, tC.expected, string(content))
+
+			cfg, err := LoadConfig(path)
+			require.NoError(t, err)
+			assert.Equal(t, SourceLocal, cfg.Source)
+			assert.Equal(t, tC.device, cfg.Device)
+		})
+	}
+}
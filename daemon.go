@@ -240,6 +240,15 @@ func getDefaultConfigPath() string {
 	return filepath.Join(home, ".airdash", "config.yaml")
 }
 
+// getDefaultStorePath returns the default history store path.
+func getDefaultStorePath() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".airdash", "history.db")
+}
+
 // isDaemonInstalled checks if the daemon is already installed.
 func isDaemonInstalled() bool {
 	plistPath, err := getPlistPath()
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/ljagiello/airdash/config"
+	"github.com/ljagiello/airdash/discovery"
+)
+
+// runDiscover implements the discover subcommand: it lists the AirGradient
+// monitors on the local network and optionally writes one into the config.
+// A nil browser or prober selects mDNS browsing and HTTP probing.
+func runDiscover(ctx context.Context, args []string, w io.Writer, browser discovery.Browser, prober discovery.Prober) error {
+	fs := flag.NewFlagSet("discover", flag.ContinueOnError)
+	configPath := fs.String("config", getDefaultConfigPath(), "path to config file")
+	timeout := fs.Duration("timeout", discovery.DefaultBrowseTimeout, "how long to listen for monitors")
+	write := fs.Bool("write", false, "write the discovered monitor into the config")
+	serial := fs.String("serial", "", "serial number of the monitor to write when several are found")
+	name := fs.String("name", "", "location name to write for the monitor")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if browser == nil {
+		browser = discovery.MDNSBrowser{Timeout: *timeout}
+	}
+	if prober == nil {
+		prober = discovery.HTTPProber{}
+	}
+
+	devices, err := discovery.Discover(ctx, browser, prober)
+	if err != nil {
+		return fmt.Errorf("browsing for monitors: %w", err)
+	}
+	if len(devices) == 0 {
+		return errors.New("no AirGradient monitors found on the local network")
+	}
+
+	writeDevices(w, devices)
+
+	if !*write {
+		return nil
+	}
+
+	device, err := chooseDevice(devices, *serial)
+	if err != nil {
+		return err
+	}
+	if err := config.SetLocalDevice(*configPath, config.Device{Host: device.ConfigHost(), Name: *name}); err != nil {
+		return fmt.Errorf("writing config %s: %w", *configPath, err)
+	}
+	_, _ = fmt.Fprintf(w, "\nWrote monitor %s to %s\n", device.Serial, *configPath)
+	return nil
+}
+
+// writeDevices prints the discovered monitors as a table.
+func writeDevices(w io.Writer, devices []discovery.Device) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "SERIAL\tIP\tHOST\tFIRMWARE\tMODEL\tSTATUS")
+	for _, d := range devices {
+		status := "ok"
+		if d.Err != nil {
+			status = "unreachable"
+		}
+		_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n",
+			orDash(d.Serial), orDash(d.IP), orDash(d.Host), orDash(d.Firmware), orDash(d.Model), status)
+	}
+	_ = tw.Flush()
+}
+
+// chooseDevice picks the monitor to write: the one matching serial, or the
+// only one found.
+func chooseDevice(devices []discovery.Device, serial string) (discovery.Device, error) {
+	if serial != "" {
+		for _, d := range devices {
+			if d.Serial == serial {
+				return d, nil
+			}
+		}
+		return discovery.Device{}, fmt.Errorf("no monitor with serial %s found", serial)
+	}
+	if len(devices) > 1 {
+		return discovery.Device{}, errors.New("several monitors found, choose one with -serial")
+	}
+	return devices[0], nil
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ljagiello/airdash/airgradient"
+	"github.com/ljagiello/airdash/config"
+	"github.com/ljagiello/airdash/discovery"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeBrowser []discovery.Entry
+
+func (b fakeBrowser) Browse(ctx context.Context) ([]discovery.Entry, error) {
+	return b, nil
+}
+
+type fakeProber map[string]airgradient.Measures
+
+func (p fakeProber) Probe(ctx context.Context, baseURL string) (airgradient.Measures, error) {
+	return p[baseURL], nil
+}
+
+func TestRunDiscover(t *testing.T) {
+	browser := fakeBrowser{
+		{Host: "airgradient_84fce612f5b8.local.", IP: net.ParseIP("192.168.1.20"), Port: 80},
+		{Host: "airgradient_ecda3b1a2b3c.local.", IP: net.ParseIP("192.168.1.21"), Port: 80},
+	}
+	prober := fakeProber{
+		"http://192.168.1.20:80": {Serialno: "84fce612f5b8", FirmwareVersion: "3.1.3", Model: "I-9PSL"},
+		"http://192.168.1.21:80": {Serialno: "ecda3b1a2b3c", FirmwareVersion: "3.1.1", Model: "O-1PST"},
+	}
+	table := "SERIAL        IP            HOST                            FIRMWARE  MODEL   STATUS\n" +
+		"84fce612f5b8  192.168.1.20  airgradient_84fce612f5b8.local  3.1.3     I-9PSL  ok\n" +
+		"ecda3b1a2b3c  192.168.1.21  airgradient_ecda3b1a2b3c.local  3.1.1     O-1PST  ok\n"
+
+	testCases := []struct {
+		name     string
+		args     []string
+		expected string
+		device   *config.Device
+		err      string
+	}{
+		{
+			"list",
+			nil,
+			table,
+			nil,
+			"",
+		},
+		{
+			"write-needs-serial",
+			[]string{"-write"},
+			table,
+			nil,
+			"several monitors found, choose one with -serial",
+		},
+		{
+			"write-unknown-serial",
+			[]string{"-write", "-serial", "000000000000"},
+			table,
+			nil,
+			"no monitor with serial 000000000000 found",
+		},
+		{
+			"write-serial",
+			[]string{"-write", "-serial", "ecda3b1a2b3c", "-name", "Balcony"},
+			table,
+			&config.Device{Host: "airgradient_ecda3b1a2b3c.local", Name: "Balcony"},
+			"",
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.name, func(t *testing.T) {
+			configPath := filepath.Join(t.TempDir(), "config.yaml")
+			args := append([]string{"-config", configPath}, tC.args...)
+
+			var out bytes.Buffer
+			err := runDiscover(context.Background(), args, &out, browser, prober)
+			if tC.err != "" {
+				assert.EqualError(t, err, tC.err)
+			} else {
+				require.NoError(t, err)
+			}
+			assert.Contains(t, out.String(), tC.expected)
+
+			if tC.device == nil {
+				_, statErr := os.Stat(configPath)
+				assert.True(t, os.IsNotExist(statErr))
+				return
+			}
+			cfg, err := config.LoadConfig(configPath)
+			require.NoError(t, err)
+			assert.Equal(t, config.SourceLocal, cfg.Source)
+			assert.Equal(t, *tC.device, cfg.Device)
+		})
+	}
+}
+
+func TestRunDiscoverNothingFound(t *testing.T) {
+	err := runDiscover(context.Background(), nil, &bytes.Buffer{}, fakeBrowser{}, fakeProber{})
+	assert.EqualError(t, err, "no AirGradient monitors found on the local network")
+}
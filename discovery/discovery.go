@@ -0,0 +1,138 @@
+// Package discovery finds AirGradient monitors on the local network.
+package discovery
+
+import (
+	"cmp"
+	"context"
+	"net"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/ljagiello/airdash/airgradient"
+)
+
+// hostPrefix starts the mDNS host name of every AirGradient monitor, which
+// is followed by its serial number.
+const hostPrefix = "airgradient_"
+
+// Entry is a service instance found on the network.
+type Entry struct {
+	// Instance is the mDNS service instance name.
+	Instance string
+	// Host is the advertised host name, e.g. "airgradient_<serial>.local".
+	Host string
+	IP   net.IP
+	Port int
+	// TXT holds the service's TXT record key/value pairs.
+	TXT map[string]string
+}
+
+// Browser lists the AirGradient service instances on the network.
+type Browser interface {
+	Browse(ctx context.Context) ([]Entry, error)
+}
+
+// Prober reads the current measures of the monitor at baseURL.
+type Prober interface {
+	Probe(ctx context.Context, baseURL string) (airgradient.Measures, error)
+}
+
+// Device is a discovered monitor.
+type Device struct {
+	Serial   string
+	Host     string
+	IP       string
+	Port     int
+	Firmware string
+	Model    string
+	// URL is the base URL of the monitor's local API.
+	URL string
+	// Err is set when the monitor was advertised but could not be probed.
+	Err error
+}
+
+// ConfigHost returns the address to store in the config: the host name,
+// which survives DHCP changes, or the URL when no host name was advertised.
+func (d Device) ConfigHost() string {
+	if d.Host != "" {
+		return d.Host
+	}
+	return d.URL
+}
+
+// Discover browses for monitors and probes each one's measures endpoint to
+// fill in what the advertisement lacks. Devices are returned once per serial
+// number, ordered by serial.
+func Discover(ctx context.Context, browser Browser, prober Prober) ([]Device, error) {
+	entries, err := browser.Browse(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var devices []Device
+	seen := make(map[string]bool)
+	for _, entry := range entries {
+		device, ok := newDevice(entry)
+		if !ok {
+			continue
+		}
+
+		m, err := prober.Probe(ctx, device.URL)
+		if err != nil {
+			device.Err = err
+		} else {
+			device.Serial = cmp.Or(device.Serial, m.Serialno)
+			device.Firmware = cmp.Or(m.FirmwareVersion, device.Firmware)
+			device.Model = cmp.Or(m.Model, device.Model)
+		}
+
+		key := cmp.Or(device.Serial, device.URL)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		devices = append(devices, device)
+	}
+
+	slices.SortFunc(devices, func(a, b Device) int {
+		return cmp.Compare(a.Serial, b.Serial)
+	})
+	return devices, nil
+}
+
+// newDevice builds a device from an advertisement, skipping entries that are
+// not AirGradient monitors or have no address.
+func newDevice(entry Entry) (Device, bool) {
+	host := strings.TrimSuffix(entry.Host, ".")
+	if entry.TXT["vendor"] == "" && entry.TXT["serialno"] == "" && !strings.HasPrefix(host, hostPrefix) {
+		return Device{}, false
+	}
+
+	device := Device{
+		Serial:   entry.TXT["serialno"],
+		Host:     host,
+		Port:     entry.Port,
+		Firmware: entry.TXT["fw_ver"],
+		Model:    entry.TXT["model"],
+	}
+	if device.Serial == "" && strings.HasPrefix(host, hostPrefix) {
+		device.Serial = strings.TrimSuffix(strings.TrimPrefix(host, hostPrefix), ".local")
+	}
+	if device.Port == 0 {
+		device.Port = 80
+	}
+
+	// Probe by IP, .local names do not resolve everywhere
+	address := host
+	if entry.IP != nil {
+		device.IP = entry.IP.String()
+		address = device.IP
+	}
+	if address == "" {
+		return Device{}, false
+	}
+	device.URL = "http://" + net.JoinHostPort(address, strconv.Itoa(device.Port))
+
+	return device, true
+}
@@ -0,0 +1,121 @@
+package discovery
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+
+	"github.com/ljagiello/airdash/airgradient"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeBrowser answers a browse with fixed entries.
+type fakeBrowser struct {
+	entries []Entry
+	err     error
+}
+
+func (b fakeBrowser) Browse(ctx context.Context) ([]Entry, error) {
+	return b.entries, b.err
+}
+
+// fakeProber answers probes by base URL.
+type fakeProber map[string]airgradient.Measures
+
+func (p fakeProber) Probe(ctx context.Context, baseURL string) (airgradient.Measures, error) {
+	m, ok := p[baseURL]
+	if !ok {
+		return airgradient.Measures{}, errors.New("connection refused")
+	}
+	return m, nil
+}
+
+func TestDiscover(t *testing.T) {
+	browser := fakeBrowser{entries: []Entry{
+		{
+			Instance: "airgradient_ecda3b1a2b3c._airgradient._tcp.local.",
+			Host:     "airgradient_ecda3b1a2b3c.local.",
+			IP:       net.ParseIP("192.168.1.21"),
+			Port:     80,
+			TXT:      map[string]string{"serialno": "ecda3b1a2b3c", "model": "O-1PST", "fw_ver": "3.1.1", "vendor": "AirGradient"},
+		},
+		{
+			Instance: "airgradient_84fce612f5b8._airgradient._tcp.local.",
+			Host:     "airgradient_84fce612f5b8.local.",
+			IP:       net.ParseIP("192.168.1.20"),
+			Port:     80,
+		},
+		// Same monitor answering twice
+		{
+			Host: "airgradient_84fce612f5b8.local.",
+			IP:   net.ParseIP("192.168.1.20"),
+			Port: 80,
+		},
+		// Not an AirGradient monitor
+		{
+			Host: "printer.local.",
+			IP:   net.ParseIP("192.168.1.30"),
+			Port: 631,
+		},
+	}}
+	prober := fakeProber{
+		"http://192.168.1.20:80": {Serialno: "84fce612f5b8", FirmwareVersion: "3.1.3", Model: "I-9PSL"},
+	}
+
+	devices, err := Discover(context.Background(), browser, prober)
+	require.NoError(t, err)
+	require.Len(t, devices, 2)
+
+	assert.Equal(t, Device{
+		Serial:   "84fce612f5b8",
+		Host:     "airgradient_84fce612f5b8.local",
+		IP:       "192.168.1.20",
+		Port:     80,
+		Firmware: "3.1.3",
+		Model:    "I-9PSL",
+		URL:      "http://192.168.1.20:80",
+	}, devices[0])
+
+	assert.Equal(t, "ecda3b1a2b3c", devices[1].Serial)
+	assert.Equal(t, "O-1PST", devices[1].Model)
+	assert.Equal(t, "3.1.1", devices[1].Firmware)
+	assert.EqualError(t, devices[1].Err, "connection refused")
+}
+
+func TestDiscoverBrowseError(t *testing.T) {
+	_, err := Discover(context.Background(), fakeBrowser{err: errors.New("no multicast interface")}, fakeProber{})
+	assert.EqualError(t, err, "no multicast interface")
+}
+
+func TestDeviceConfigHost(t *testing.T) {
+	assert.Equal(t, "airgradient_84fce612f5b8.local", Device{Host: "airgradient_84fce612f5b8.local", URL: "http://192.168.1.20:80"}.ConfigHost())
+	assert.Equal(t, "http://192.168.1.20:80", Device{URL: "http://192.168.1.20:80"}.ConfigHost())
+}
+
+func TestDiscoverWithHTTPProber(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.ServeFile(w, r, "../airgradient/testdata/local-measures-current.json")
+	}))
+	defer server.Close()
+
+	serverURL, err := url.Parse(server.URL)
+	require.NoError(t, err)
+	port, err := strconv.Atoi(serverURL.Port())
+	require.NoError(t, err)
+
+	browser := fakeBrowser{entries: []Entry{
+		{Host: "airgradient_84fce612f5b8.local.", IP: net.ParseIP(serverURL.Hostname()), Port: port},
+	}}
+
+	devices, err := Discover(context.Background(), browser, HTTPProber{})
+	require.NoError(t, err)
+	require.Len(t, devices, 1)
+	assert.NoError(t, devices[0].Err)
+	assert.Equal(t, "3.1.3", devices[0].Firmware)
+}
@@ -0,0 +1,70 @@
+package discovery
+
+import (
+	"context"
+	"io"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/mdns"
+)
+
+// ServiceName is the DNS-SD service type advertised by AirGradient monitors.
+const ServiceName = "_airgradient._tcp"
+
+// DefaultBrowseTimeout is how long MDNSBrowser listens for answers.
+const DefaultBrowseTimeout = 3 * time.Second
+
+// MDNSBrowser browses for monitors with multicast DNS.
+type MDNSBrowser struct {
+	// Timeout is how long to listen for answers, DefaultBrowseTimeout if 0.
+	Timeout time.Duration
+}
+
+// Browse sends an mDNS query and collects the answers until the timeout.
+func (b MDNSBrowser) Browse(ctx context.Context) ([]Entry, error) {
+	timeout := b.Timeout
+	if timeout == 0 {
+		timeout = DefaultBrowseTimeout
+	}
+
+	answers := make(chan *mdns.ServiceEntry, 16)
+	done := make(chan []Entry)
+	go func() {
+		var entries []Entry
+		for answer := range answers {
+			entries = append(entries, newEntry(answer))
+		}
+		done <- entries
+	}()
+
+	params := mdns.DefaultParams(ServiceName)
+	params.Timeout = timeout
+	params.Entries = answers
+	params.DisableIPv6 = true
+	params.Logger = log.New(io.Discard, "", 0)
+	err := mdns.QueryContext(ctx, params)
+	close(answers)
+	entries := <-done
+	if err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// newEntry converts an mDNS answer, splitting its key=value TXT fields.
+func newEntry(answer *mdns.ServiceEntry) Entry {
+	entry := Entry{
+		Instance: answer.Name,
+		Host:     answer.Host,
+		IP:       answer.AddrV4,
+		Port:     answer.Port,
+		TXT:      make(map[string]string),
+	}
+	for _, field := range answer.InfoFields {
+		key, value, _ := strings.Cut(field, "=")
+		entry.TXT[strings.ToLower(key)] = value
+	}
+	return entry
+}
@@ -0,0 +1,36 @@
+package discovery
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/ljagiello/airdash/airgradient"
+)
+
+// DefaultProbeTimeout bounds a single probe.
+const DefaultProbeTimeout = 3 * time.Second
+
+// HTTPProber probes monitors through their local HTTP API.
+type HTTPProber struct {
+	// Options configure the client, e.g. airgradient.WithHTTPClient.
+	Options []airgradient.Option
+}
+
+// Probe reads the monitor's current measures without retrying, a device that
+// does not answer promptly is reported as unreachable.
+func (p HTTPProber) Probe(ctx context.Context, baseURL string) (airgradient.Measures, error) {
+	opts := append([]airgradient.Option{
+		airgradient.WithTimeout(DefaultProbeTimeout),
+		airgradient.WithRetryPolicy(airgradient.RetryPolicy{MaxAttempts: 1}),
+	}, p.Options...)
+
+	measures, err := airgradient.NewLocalSource(baseURL, "", opts...).Current(ctx)
+	if err != nil {
+		return airgradient.Measures{}, err
+	}
+	if len(measures) == 0 {
+		return airgradient.Measures{}, errors.New("no measures")
+	}
+	return measures[0], nil
+}
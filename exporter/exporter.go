@@ -0,0 +1,328 @@
+// Package exporter exposes measures and the health of the fetches behind
+// them as Prometheus metrics.
+package exporter
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/ljagiello/airdash/airgradient"
+	"github.com/ljagiello/airdash/classify"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// DefaultListen is the address the exporter serves /metrics on when none is
+// configured.
+const DefaultListen = ":9101"
+
+// Fetch error types, the type label of airdash_fetch_errors_total.
+const (
+	ErrorUnauthorized = "unauthorized"
+	ErrorNotFound     = "not_found"
+	ErrorRateLimited  = "rate_limited"
+	ErrorUnavailable  = "unavailable"
+	ErrorBadPayload   = "bad_payload"
+	ErrorNetwork      = "network"
+	ErrorOther        = "other"
+)
+
+var errorTypes = []string{
+	ErrorUnauthorized, ErrorNotFound, ErrorRateLimited, ErrorUnavailable,
+	ErrorBadPayload, ErrorNetwork, ErrorOther,
+}
+
+// locationLabels identify the location of every measure metric.
+var locationLabels = []string{"location_id", "location_name", "serialno"}
+
+// Exporter collects the latest measures of every location and the health of
+// the fetches. It is safe for concurrent use.
+type Exporter struct {
+	registry  *prometheus.Registry
+	locations []string
+	now       func() time.Time
+
+	// Descriptions of the measure metrics
+	metrics    []airgradient.Metric
+	metricDesc []*prometheus.Desc
+	extraDesc  map[string]*prometheus.Desc
+	levelDesc  *prometheus.Desc
+	levels     *classify.Classifier
+	staleDesc  *prometheus.Desc
+	maxAge     time.Duration
+
+	fetchDuration prometheus.Histogram
+	fetchErrors   *prometheus.CounterVec
+	lastSuccess   prometheus.Gauge
+
+	mu       sync.Mutex
+	measures []airgradient.Measures
+}
+
+// Option configures an Exporter.
+type Option func(*Exporter)
+
+// WithLocations limits the exported measures to these locations, by ID or
+// name. Empty means every location.
+func WithLocations(selectors []string) Option {
+	return func(e *Exporter) {
+		e.locations = selectors
+	}
+}
+
+// WithClock sets the clock fetches are timed with, time.Now by default.
+func WithClock(now func() time.Time) Option {
+	return func(e *Exporter) {
+		e.now = now
+	}
+}
+
+// WithLevels exports the level of every classified reading as
+// airgradient_level, from 1 (good) to 4 (hazardous). A nil classifier uses
+// the default bands.
+func WithLevels(levels *classify.Classifier) Option {
+	return func(e *Exporter) {
+		e.levels = levels
+		e.levelDesc = prometheus.NewDesc("airgradient_level",
+			"Level of a reading: 1 good, 2 moderate, 3 poor, 4 hazardous.",
+			append(append([]string{}, locationLabels...), "metric"), nil)
+	}
+}
+
+// WithMaxAge exports airgradient_stale, 1 for locations whose readings are
+// older than maxAge or have no timestamp and 0 otherwise.
+func WithMaxAge(maxAge time.Duration) Option {
+	return func(e *Exporter) {
+		e.maxAge = maxAge
+		e.staleDesc = prometheus.NewDesc("airgradient_stale",
+			"Whether the readings are older than the configured maximum age, 1 or 0.", locationLabels, nil)
+	}
+}
+
+// New returns an exporter with its own registry, which also carries the Go
+// runtime and process metrics.
+func New(opts ...Option) *Exporter {
+	e := &Exporter{
+		registry: prometheus.NewRegistry(),
+		now:      time.Now,
+		metrics:  airgradient.Metrics,
+		fetchDuration: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Name:    "airdash_fetch_duration_seconds",
+			Help:    "Duration of fetching the current measures, including retries.",
+			Buckets: prometheus.DefBuckets,
+		}),
+		fetchErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "airdash_fetch_errors_total",
+			Help: "Failed fetches of the current measures by error type.",
+		}, []string{"type"}),
+		lastSuccess: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "airdash_last_success_timestamp_seconds",
+			Help: "Unix time of the last successful fetch of the current measures.",
+		}),
+	}
+	for _, opt := range opts {
+		opt(e)
+	}
+
+	for _, metric := range e.metrics {
+		e.metricDesc = append(e.metricDesc, prometheus.NewDesc(
+			"airgradient_"+snakeCase(metric.Name), metricHelp(metric), locationLabels, nil,
+		))
+	}
+	e.extraDesc = map[string]*prometheus.Desc{
+		"pm02Raw": prometheus.NewDesc("airgradient_pm02_raw",
+			"PM2.5 before the correction named by airgradient_info, in µg/m³.", locationLabels, nil),
+		"ledCo2Threshold1": prometheus.NewDesc("airgradient_led_co2_threshold1",
+			"CO2 level at which the monitor's LEDs turn from green to yellow, in ppm.", locationLabels, nil),
+		"ledCo2Threshold2": prometheus.NewDesc("airgradient_led_co2_threshold2",
+			"CO2 level at which the monitor's LEDs turn from yellow to orange, in ppm.", locationLabels, nil),
+		"ledCo2ThresholdEnd": prometheus.NewDesc("airgradient_led_co2_threshold_end",
+			"CO2 level at which the monitor's LEDs turn red, in ppm.", locationLabels, nil),
+		"timestamp": prometheus.NewDesc("airgradient_measurement_timestamp_seconds",
+			"Unix time the measures were taken at.", locationLabels, nil),
+		"info": prometheus.NewDesc("airgradient_info",
+			"Monitor metadata, always 1.",
+			append(append([]string{}, locationLabels...), "firmware_version", "model", "led_mode", "pm02_correction"), nil),
+	}
+
+	// Start every error type at zero so rates work from the first failure
+	for _, errorType := range errorTypes {
+		e.fetchErrors.WithLabelValues(errorType)
+	}
+
+	e.registry.MustRegister(
+		e,
+		e.fetchDuration,
+		e.fetchErrors,
+		e.lastSuccess,
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+	return e
+}
+
+// Handler serves the metrics in the Prometheus exposition format.
+func (e *Exporter) Handler() http.Handler {
+	return promhttp.HandlerFor(e.registry, promhttp.HandlerOpts{})
+}
+
+// Update replaces the exported measures. Locations missing from measures
+// are no longer exported.
+func (e *Exporter) Update(measures []airgradient.Measures) {
+	selected := airgradient.SelectLocations(measures, e.locations)
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.measures = selected
+}
+
+// ObserveFetch records a fetch that took duration and failed with err, or
+// succeeded when err is nil.
+func (e *Exporter) ObserveFetch(duration time.Duration, err error) {
+	e.fetchDuration.Observe(duration.Seconds())
+	if err != nil {
+		e.fetchErrors.WithLabelValues(ErrorType(err)).Inc()
+		return
+	}
+	e.lastSuccess.Set(float64(e.now().UnixNano()) / float64(time.Second))
+}
+
+// Instrument returns a source that records every fetch of source and
+// exports the measures it returns.
+func (e *Exporter) Instrument(source airgradient.Source) airgradient.Source {
+	return instrumentedSource{source: source, exporter: e}
+}
+
+type instrumentedSource struct {
+	source   airgradient.Source
+	exporter *Exporter
+}
+
+// Current fetches the measures from the wrapped source and records the
+// fetch.
+func (s instrumentedSource) Current(ctx context.Context) ([]airgradient.Measures, error) {
+	start := s.exporter.now()
+	measures, err := s.source.Current(ctx)
+	s.exporter.ObserveFetch(s.exporter.now().Sub(start), err)
+	if err == nil {
+		s.exporter.Update(measures)
+	}
+	return measures, err
+}
+
+// Describe implements prometheus.Collector.
+func (e *Exporter) Describe(ch chan<- *prometheus.Desc) {
+	for _, desc := range e.metricDesc {
+		ch <- desc
+	}
+	for _, desc := range e.extraDesc {
+		ch <- desc
+	}
+	if e.levelDesc != nil {
+		ch <- e.levelDesc
+	}
+	if e.staleDesc != nil {
+		ch <- e.staleDesc
+	}
+}
+
+// Collect implements prometheus.Collector. Missing readings are left out.
+func (e *Exporter) Collect(ch chan<- prometheus.Metric) {
+	e.mu.Lock()
+	measures := e.measures
+	e.mu.Unlock()
+
+	for _, m := range measures {
+		labels := []string{strconv.Itoa(m.LocationID), m.LocationName, m.Serialno}
+		gauge := func(desc *prometheus.Desc, v airgradient.Value) {
+			if v.Valid {
+				ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, v.Float64, labels...)
+			}
+		}
+
+		for i, metric := range e.metrics {
+			gauge(e.metricDesc[i], metric.Value(m))
+		}
+		gauge(e.extraDesc["pm02Raw"], m.Pm02Raw)
+		if m.LedCo2ThresholdEnd != 0 {
+			gauge(e.extraDesc["ledCo2Threshold1"], airgradient.NewValue(m.LedCo2Threshold1))
+			gauge(e.extraDesc["ledCo2Threshold2"], airgradient.NewValue(m.LedCo2Threshold2))
+			gauge(e.extraDesc["ledCo2ThresholdEnd"], airgradient.NewValue(m.LedCo2ThresholdEnd))
+		}
+		if !m.Timestamp.IsZero() {
+			gauge(e.extraDesc["timestamp"], airgradient.NewValue(float64(m.Timestamp.Unix())))
+		}
+		if e.staleDesc != nil {
+			// Readings without a timestamp are stale, as their age is unknown
+			stale := 0.0
+			if m.Timestamp.IsZero() || e.now().Sub(m.Timestamp) > e.maxAge {
+				stale = 1
+			}
+			gauge(e.staleDesc, airgradient.NewValue(stale))
+		}
+		ch <- prometheus.MustNewConstMetric(e.extraDesc["info"], prometheus.GaugeValue, 1,
+			append(labels, m.FirmwareVersion, m.Model, m.LedMode, m.Pm02Correction)...)
+		if e.levelDesc != nil {
+			for _, metric := range classify.Metrics {
+				if level := e.levels.Metric(metric, m); level != classify.Unknown {
+					ch <- prometheus.MustNewConstMetric(e.levelDesc, prometheus.GaugeValue, float64(level),
+						append(labels, metric)...)
+				}
+			}
+		}
+	}
+}
+
+// ErrorType classifies a failed fetch for airdash_fetch_errors_total.
+func ErrorType(err error) string {
+	var urlErr *url.Error
+	switch {
+	case errors.Is(err, airgradient.ErrUnauthorized):
+		return ErrorUnauthorized
+	case errors.Is(err, airgradient.ErrNotFound):
+		return ErrorNotFound
+	case errors.Is(err, airgradient.ErrRateLimited):
+		return ErrorRateLimited
+	case errors.Is(err, airgradient.ErrUnavailable):
+		return ErrorUnavailable
+	case errors.Is(err, airgradient.ErrBadPayload):
+		return ErrorBadPayload
+	case errors.As(err, &urlErr):
+		return ErrorNetwork
+	default:
+		return ErrorOther
+	}
+}
+
+// metricHelp describes a measure metric, e.g. "AirGradient rco2 reading in
+// ppm.".
+func metricHelp(metric airgradient.Metric) string {
+	help := "AirGradient " + metric.Name + " reading"
+	if metric.Unit != "" {
+		help += " in " + metric.Unit
+	}
+	return help + "."
+}
+
+// snakeCase turns an API field name into a metric name, e.g. "pm003Count"
+// into "pm003_count".
+func snakeCase(name string) string {
+	var b strings.Builder
+	for i, r := range name {
+		if unicode.IsUpper(r) {
+			if i > 0 {
+				b.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
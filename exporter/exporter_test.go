@@ -0,0 +1,202 @@
+package exporter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ljagiello/airdash/airgradient"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testMeasures = []airgradient.Measures{
+	{
+		LocationID:         12345,
+		LocationName:       "Test Loc",
+		Serialno:           "aabb12",
+		FirmwareVersion:    "3.1.1",
+		Model:              "I-9PSL",
+		LedMode:            "co2",
+		Pm02:               airgradient.NewValue(4),
+		Atmp:               airgradient.NewValue(24.3),
+		Rhum:               airgradient.NewValue(52),
+		Rco2:               airgradient.NewValue(548),
+		Timestamp:          time.Date(2023, 10, 10, 3, 42, 11, 0, time.UTC),
+		LedCo2Threshold1:   1000,
+		LedCo2Threshold2:   2000,
+		LedCo2ThresholdEnd: 4000,
+	},
+	{
+		LocationID:   23456,
+		LocationName: "Meeting Room",
+		Serialno:     "ccdd34",
+		Pm02:         airgradient.NewValue(12),
+		Rco2:         airgradient.NewValue(1210),
+	},
+}
+
+type fakeSource struct {
+	measures []airgradient.Measures
+	err      error
+}
+
+func (s fakeSource) Current(context.Context) ([]airgradient.Measures, error) {
+	return s.measures, s.err
+}
+
+func TestCollectMeasures(t *testing.T) {
+	e := New()
+	e.Update(testMeasures)
+
+	expected := `
+# HELP airgradient_rco2 AirGradient rco2 reading in ppm.
+# TYPE airgradient_rco2 gauge
+airgradient_rco2{location_id="12345",location_name="Test Loc",serialno="aabb12"} 548
+airgradient_rco2{location_id="23456",location_name="Meeting Room",serialno="ccdd34"} 1210
+# HELP airgradient_atmp AirGradient atmp reading in °C.
+# TYPE airgradient_atmp gauge
+airgradient_atmp{location_id="12345",location_name="Test Loc",serialno="aabb12"} 24.3
+# HELP airgradient_led_co2_threshold_end CO2 level at which the monitor's LEDs turn red, in ppm.
+# TYPE airgradient_led_co2_threshold_end gauge
+airgradient_led_co2_threshold_end{location_id="12345",location_name="Test Loc",serialno="aabb12"} 4000
+# HELP airgradient_measurement_timestamp_seconds Unix time the measures were taken at.
+# TYPE airgradient_measurement_timestamp_seconds gauge
+airgradient_measurement_timestamp_seconds{location_id="12345",location_name="Test Loc",serialno="aabb12"} 1.696909331e+09
+# HELP airgradient_info Monitor metadata, always 1.
+# TYPE airgradient_info gauge
+airgradient_info{firmware_version="",led_mode="",location_id="23456",location_name="Meeting Room",model="",pm02_correction="",serialno="ccdd34"} 1
+airgradient_info{firmware_version="3.1.1",led_mode="co2",location_id="12345",location_name="Test Loc",model="I-9PSL",pm02_correction="",serialno="aabb12"} 1
+`
+	require.NoError(t, testutil.CollectAndCompare(e, strings.NewReader(expected),
+		"airgradient_rco2", "airgradient_atmp", "airgradient_led_co2_threshold_end",
+		"airgradient_measurement_timestamp_seconds", "airgradient_info"))
+
+	// Missing readings are left out rather than exported as 0
+	assert.Equal(t, 1, testutil.CollectAndCount(e, "airgradient_pm01", "airgradient_atmp"))
+	assert.Equal(t, 2, testutil.CollectAndCount(e, "airgradient_pm003_count", "airgradient_pm02"))
+}
+
+func TestCollectLevels(t *testing.T) {
+	e := New(WithLevels(nil))
+	e.Update(testMeasures)
+
+	expected := `
+# HELP airgradient_level Level of a reading: 1 good, 2 moderate, 3 poor, 4 hazardous.
+# TYPE airgradient_level gauge
+airgradient_level{location_id="12345",location_name="Test Loc",metric="atmp",serialno="aabb12"} 1
+airgradient_level{location_id="12345",location_name="Test Loc",metric="pm02",serialno="aabb12"} 1
+airgradient_level{location_id="12345",location_name="Test Loc",metric="rco2",serialno="aabb12"} 1
+airgradient_level{location_id="12345",location_name="Test Loc",metric="rhum",serialno="aabb12"} 1
+airgradient_level{location_id="23456",location_name="Meeting Room",metric="pm02",serialno="ccdd34"} 2
+airgradient_level{location_id="23456",location_name="Meeting Room",metric="rco2",serialno="ccdd34"} 2
+`
+	require.NoError(t, testutil.CollectAndCompare(e, strings.NewReader(expected), "airgradient_level"))
+
+	// Levels are only exported when enabled
+	e = New()
+	e.Update(testMeasures)
+	assert.Equal(t, 0, testutil.CollectAndCount(e, "airgradient_level"))
+}
+
+func TestCollectStale(t *testing.T) {
+	now := testMeasures[0].Timestamp.Add(time.Hour)
+	e := New(WithClock(func() time.Time { return now }), WithMaxAge(10*time.Minute))
+	fresh := testMeasures[0]
+	fresh.LocationID = 34567
+	fresh.Timestamp = now.Add(-time.Minute)
+	e.Update(append([]airgradient.Measures{fresh}, testMeasures...))
+
+	// Measures without a timestamp are stale
+	expected := `
+# HELP airgradient_stale Whether the readings are older than the configured maximum age, 1 or 0.
+# TYPE airgradient_stale gauge
+airgradient_stale{location_id="12345",location_name="Test Loc",serialno="aabb12"} 1
+airgradient_stale{location_id="23456",location_name="Meeting Room",serialno="ccdd34"} 1
+airgradient_stale{location_id="34567",location_name="Test Loc",serialno="aabb12"} 0
+`
+	require.NoError(t, testutil.CollectAndCompare(e, strings.NewReader(expected), "airgradient_stale"))
+}
+
+func TestUpdateSelectsLocations(t *testing.T) {
+	e := New(WithLocations([]string{"meeting room"}))
+	e.Update(testMeasures)
+	assert.Equal(t, 1, testutil.CollectAndCount(e, "airgradient_info"))
+
+	// A later update drops locations that are no longer reported
+	e = New()
+	e.Update(testMeasures)
+	e.Update(testMeasures[:1])
+	assert.Equal(t, 1, testutil.CollectAndCount(e, "airgradient_info"))
+}
+
+func TestInstrument(t *testing.T) {
+	now := time.Date(2023, 10, 10, 3, 42, 0, 0, time.UTC)
+	clock := func() time.Time {
+		now = now.Add(250 * time.Millisecond)
+		return now
+	}
+	e := New(WithClock(clock))
+
+	_, err := e.Instrument(fakeSource{err: &airgradient.APIError{StatusCode: 401}}).Current(context.Background())
+	require.Error(t, err)
+	measures, err := e.Instrument(fakeSource{measures: testMeasures}).Current(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, testMeasures, measures)
+
+	assert.InDelta(t, 1.0, testutil.ToFloat64(e.fetchErrors.WithLabelValues(ErrorUnauthorized)), 0)
+	assert.InDelta(t, 0.0, testutil.ToFloat64(e.fetchErrors.WithLabelValues(ErrorNetwork)), 0)
+	assert.InDelta(t, float64(now.UnixMilli())/1000, testutil.ToFloat64(e.lastSuccess), 1e-3)
+	assert.Equal(t, 2, testutil.CollectAndCount(e, "airgradient_info"))
+
+	expected := `
+# HELP airdash_fetch_duration_seconds Duration of fetching the current measures, including retries.
+# TYPE airdash_fetch_duration_seconds histogram
+airdash_fetch_duration_seconds_bucket{le="0.005"} 0
+airdash_fetch_duration_seconds_bucket{le="0.01"} 0
+airdash_fetch_duration_seconds_bucket{le="0.025"} 0
+airdash_fetch_duration_seconds_bucket{le="0.05"} 0
+airdash_fetch_duration_seconds_bucket{le="0.1"} 0
+airdash_fetch_duration_seconds_bucket{le="0.25"} 2
+airdash_fetch_duration_seconds_bucket{le="0.5"} 2
+airdash_fetch_duration_seconds_bucket{le="1"} 2
+airdash_fetch_duration_seconds_bucket{le="2.5"} 2
+airdash_fetch_duration_seconds_bucket{le="5"} 2
+airdash_fetch_duration_seconds_bucket{le="10"} 2
+airdash_fetch_duration_seconds_bucket{le="+Inf"} 2
+airdash_fetch_duration_seconds_sum 0.5
+airdash_fetch_duration_seconds_count 2
+`
+	require.NoError(t, testutil.CollectAndCompare(e.fetchDuration, strings.NewReader(expected)))
+}
+
+func TestErrorType(t *testing.T) {
+	testCases := []struct {
+		err      error
+		expected string
+	}{
+		{&airgradient.APIError{StatusCode: 403}, ErrorUnauthorized},
+		{&airgradient.APIError{StatusCode: 404}, ErrorNotFound},
+		{&airgradient.APIError{StatusCode: 429}, ErrorRateLimited},
+		{&airgradient.APIError{StatusCode: 503}, ErrorUnavailable},
+		{fmt.Errorf("parsing: %w", airgradient.ErrBadPayload), ErrorBadPayload},
+		{&url.Error{Op: "Get", URL: "https://api.airgradient.com", Err: errors.New("connection refused")}, ErrorNetwork},
+		{context.Canceled, ErrorOther},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.expected, func(t *testing.T) {
+			assert.Equal(t, tC.expected, ErrorType(tC.err))
+		})
+	}
+}
+
+func TestSnakeCase(t *testing.T) {
+	assert.Equal(t, "pm02", snakeCase("pm02"))
+	assert.Equal(t, "pm003_count", snakeCase("pm003Count"))
+	assert.Equal(t, "absolute_humidity", snakeCase("absoluteHumidity"))
+}
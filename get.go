@@ -0,0 +1,332 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/ljagiello/airdash/airgradient"
+	"github.com/ljagiello/airdash/render"
+	"gopkg.in/yaml.v3"
+)
+
+// Output formats supported by the get subcommand.
+const (
+	formatTable = "table"
+	formatJSON  = "json"
+	formatYAML  = "yaml"
+	formatCSV   = "csv"
+	formatKV    = "kv"
+)
+
+// formats lists the output formats of the get subcommand.
+var formats = []string{formatTable, formatJSON, formatYAML, formatCSV, formatKV}
+
+// errUnknownFormat is returned for an unsupported -format.
+var errUnknownFormat = errors.New("unknown output format")
+
+// measureRecord is the flattened, unit-converted view of airgradient.Measures
+// that the get subcommand prints. Missing readings are nil and left out of
+// the exports. Pm02Raw and Pm02Correction are only set for corrected PM2.5
+// readings. Stale tells whether the readings are older than the configured
+// maximum age, and is nil for history rows it does not apply to. The derived
+// comfort metrics follow the sensor readings, with temperatures in TempUnit.
+type measureRecord struct {
+	LocationID     int       `json:"locationId" yaml:"locationId"`
+	LocationName   string    `json:"locationName" yaml:"locationName"`
+	Serialno       string    `json:"serialno" yaml:"serialno"`
+	Timestamp      time.Time `json:"timestamp" yaml:"timestamp"`
+	Stale          *bool     `json:"stale,omitempty" yaml:"stale,omitempty"`
+	Temperature    *float64  `json:"temperature,omitempty" yaml:"temperature,omitempty"`
+	TempUnit       string    `json:"tempUnit" yaml:"tempUnit"`
+	Humidity       *float64  `json:"humidity,omitempty" yaml:"humidity,omitempty"`
+	Co2            *float64  `json:"co2,omitempty" yaml:"co2,omitempty"`
+	Pm01           *float64  `json:"pm01,omitempty" yaml:"pm01,omitempty"`
+	Pm02           *float64  `json:"pm02,omitempty" yaml:"pm02,omitempty"`
+	Pm02Raw        *float64  `json:"pm02Raw,omitempty" yaml:"pm02Raw,omitempty"`
+	Pm02Correction string    `json:"pm02Correction,omitempty" yaml:"pm02Correction,omitempty"`
+	Pm10           *float64  `json:"pm10,omitempty" yaml:"pm10,omitempty"`
+	Pm003Count     *float64  `json:"pm003Count,omitempty" yaml:"pm003Count,omitempty"`
+	Tvoc           *float64  `json:"tvoc,omitempty" yaml:"tvoc,omitempty"`
+	TvocIndex      *float64  `json:"tvocIndex,omitempty" yaml:"tvocIndex,omitempty"`
+	NoxIndex       *float64  `json:"noxIndex,omitempty" yaml:"noxIndex,omitempty"`
+	Wifi           *float64  `json:"wifi,omitempty" yaml:"wifi,omitempty"`
+
+	DewPoint         *float64 `json:"dewPoint,omitempty" yaml:"dewPoint,omitempty"`
+	HeatIndex        *float64 `json:"heatIndex,omitempty" yaml:"heatIndex,omitempty"`
+	Humidex          *float64 `json:"humidex,omitempty" yaml:"humidex,omitempty"`
+	AbsoluteHumidity *float64 `json:"absoluteHumidity,omitempty" yaml:"absoluteHumidity,omitempty"`
+	WetBulb          *float64 `json:"wetBulb,omitempty" yaml:"wetBulb,omitempty"`
+}
+
+// newMeasureRecord converts the measures into a record using the given
+// temperature unit. Temperatures, derived metrics and a corrected PM2.5
+// reading are rounded to two decimals, as in the status bar, to keep
+// conversion noise out of the exports.
+func newMeasureRecord(m airgradient.Measures, tempUnit string) measureRecord {
+	unit := "C"
+	if tempUnit == "F" {
+		unit = "F"
+	}
+	if m.Pm02Correction != "" {
+		m.Pm02 = round2(m.Pm02)
+	}
+	temperature := func(v airgradient.Value) *float64 {
+		return round2(render.ConvertTemperatureValue(v, tempUnit)).Ptr()
+	}
+	return measureRecord{
+		LocationID:     m.LocationID,
+		LocationName:   m.LocationName,
+		Serialno:       m.Serialno,
+		Timestamp:      m.Timestamp,
+		Temperature:    temperature(m.Atmp),
+		TempUnit:       unit,
+		Humidity:       m.Rhum.Ptr(),
+		Co2:            m.Rco2.Ptr(),
+		Pm01:           m.Pm01.Ptr(),
+		Pm02:           m.Pm02.Ptr(),
+		Pm02Raw:        m.Pm02Raw.Ptr(),
+		Pm02Correction: m.Pm02Correction,
+		Pm10:           m.Pm10.Ptr(),
+		Pm003Count:     m.Pm003Count.Ptr(),
+		Tvoc:           m.Tvoc.Ptr(),
+		TvocIndex:      m.TvocIndex.Ptr(),
+		NoxIndex:       m.NoxIndex.Ptr(),
+		Wifi:           m.Wifi.Ptr(),
+
+		DewPoint:         temperature(m.DewPoint()),
+		HeatIndex:        temperature(m.HeatIndex()),
+		Humidex:          round2(m.Humidex()).Ptr(),
+		AbsoluteHumidity: round2(m.AbsoluteHumidity()).Ptr(),
+		WetBulb:          temperature(m.WetBulb()),
+	}
+}
+
+// round2 rounds a reading to two decimals.
+func round2(v airgradient.Value) airgradient.Value {
+	v.Float64 = math.Round(v.Float64*100) / 100
+	return v
+}
+
+// recordField is a single key/value pair of a record.
+type recordField struct {
+	key     string
+	value   string
+	missing bool
+}
+
+// fields returns the record as ordered key/value pairs for the CSV and
+// key=value formats.
+func (r measureRecord) fields() []recordField {
+	str := func(key, value string) recordField { return recordField{key: key, value: value} }
+	opt := func(key, value string) recordField { return recordField{key: key, value: value, missing: value == ""} }
+	num := func(key string, v *float64) recordField {
+		if v == nil {
+			return recordField{key: key, missing: true}
+		}
+		return recordField{key: key, value: strconv.FormatFloat(*v, 'f', -1, 64)}
+	}
+	fields := []recordField{
+		str("location_id", strconv.Itoa(r.LocationID)),
+		str("location_name", r.LocationName),
+		str("serialno", r.Serialno),
+		str("timestamp", r.Timestamp.Format(time.RFC3339)),
+	}
+	if r.Stale != nil {
+		fields = append(fields, str("stale", strconv.FormatBool(*r.Stale)))
+	}
+	return append(fields,
+		num("temperature", r.Temperature),
+		str("temp_unit", r.TempUnit),
+		num("humidity", r.Humidity),
+		num("co2", r.Co2),
+		num("pm01", r.Pm01),
+		num("pm02", r.Pm02),
+		num("pm02_raw", r.Pm02Raw),
+		opt("pm02_correction", r.Pm02Correction),
+		num("pm10", r.Pm10),
+		num("pm003_count", r.Pm003Count),
+		num("tvoc", r.Tvoc),
+		num("tvoc_index", r.TvocIndex),
+		num("nox_index", r.NoxIndex),
+		num("wifi", r.Wifi),
+		num("dew_point", r.DewPoint),
+		num("heat_index", r.HeatIndex),
+		num("humidex", r.Humidex),
+		num("absolute_humidity", r.AbsoluteHumidity),
+		num("wet_bulb", r.WetBulb),
+	)
+}
+
+// runGet implements the get subcommand: it prints the current measures of
+// the configured locations without starting the GUI.
+func runGet(ctx context.Context, args []string, w io.Writer) error {
+	fs := flag.NewFlagSet("get", flag.ContinueOnError)
+	configPath := fs.String("config", getDefaultConfigPath(), "path to config file")
+	format := fs.String("format", formatTable, "output format: table, json, yaml, csv or kv")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if !slices.Contains(formats, *format) {
+		return fmt.Errorf("%w: %q, expected %s", errUnknownFormat, *format, strings.Join(formats, ", "))
+	}
+
+	cfg, err := loadConfig(*configPath)
+	if err != nil {
+		return fmt.Errorf("loading config %s: %w", *configPath, err)
+	}
+
+	source, err := newSource(cfg)
+	if err != nil {
+		return err
+	}
+
+	measures, err := source.Current(ctx)
+	if err != nil {
+		return fmt.Errorf("fetching measures (%s): %w", render.ErrorState(err), err)
+	}
+
+	selected := airgradient.SelectLocations(measures, cfg.Locations)
+	if len(selected) == 0 {
+		return fmt.Errorf("no measures for locations %v: %w", cfg.Locations, airgradient.ErrNotFound)
+	}
+
+	opts := render.Options{MaxAge: cfg.StaleAfter()}
+	records := make([]measureRecord, 0, len(selected))
+	for _, m := range selected {
+		record := newMeasureRecord(m, cfg.TempUnit)
+		stale := render.IsStale(m, opts)
+		record.Stale = &stale
+		records = append(records, record)
+	}
+
+	return writeRecords(w, records, *format)
+}
+
+// writeRecords writes the records in the requested format.
+func writeRecords(w io.Writer, records []measureRecord, format string) error {
+	switch format {
+	case formatTable:
+		return writeTable(w, records)
+	case formatJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(records)
+	case formatYAML:
+		enc := yaml.NewEncoder(w)
+		enc.SetIndent(2)
+		if err := enc.Encode(records); err != nil {
+			return err
+		}
+		return enc.Close()
+	case formatCSV:
+		return writeCSV(w, records)
+	case formatKV:
+		return writeKV(w, records)
+	default:
+		return fmt.Errorf("%w: %q", errUnknownFormat, format)
+	}
+}
+
+func writeTable(w io.Writer, records []measureRecord) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "LOCATION\tTEMP\tHUMIDITY\tCO2\tPM2.5\tTVOC INDEX\tNOX INDEX\tUPDATED")
+	for _, r := range records {
+		name := r.LocationName
+		if name == "" {
+			name = strconv.Itoa(r.LocationID)
+		}
+		_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			name,
+			tableCell(r.Temperature, 1, " °"+r.TempUnit),
+			tableCell(r.Humidity, 1, " %"),
+			tableCell(r.Co2, 0, " ppm"),
+			pm02Cell(r),
+			tableCell(r.TvocIndex, 0, ""),
+			tableCell(r.NoxIndex, 0, ""),
+			updatedCell(r),
+		)
+	}
+	return tw.Flush()
+}
+
+// updatedCell formats the time of the readings, marking stale ones.
+func updatedCell(r measureRecord) string {
+	if r.Stale != nil && *r.Stale {
+		return r.Timestamp.Format(time.RFC3339) + " (stale)"
+	}
+	return r.Timestamp.Format(time.RFC3339)
+}
+
+// pm02Cell formats the PM2.5 reading, naming the correction applied to it.
+func pm02Cell(r measureRecord) string {
+	cell := tableCell(r.Pm02, 0, " µg/m³")
+	if r.Pm02 != nil && r.Pm02Correction != "" {
+		cell += " (" + r.Pm02Correction + ")"
+	}
+	return cell
+}
+
+// tableCell formats a reading with its unit, or "—" when it is missing.
+func tableCell(v *float64, decimals int, unit string) string {
+	if v == nil {
+		return "—"
+	}
+	return strconv.FormatFloat(*v, 'f', decimals, 64) + unit
+}
+
+func writeCSV(w io.Writer, records []measureRecord) error {
+	cw := csv.NewWriter(w)
+	for i, r := range records {
+		fields := r.fields()
+		if i == 0 {
+			header := make([]string, len(fields))
+			for j, f := range fields {
+				header[j] = f.key
+			}
+			if err := cw.Write(header); err != nil {
+				return err
+			}
+		}
+		// Missing readings are left as empty cells
+		row := make([]string, len(fields))
+		for j, f := range fields {
+			row[j] = f.value
+		}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func writeKV(w io.Writer, records []measureRecord) error {
+	for _, r := range records {
+		pairs := make([]string, 0, 32)
+		for _, f := range r.fields() {
+			if f.missing {
+				continue
+			}
+			value := f.value
+			if value == "" || strings.ContainsAny(value, " \t\"=") {
+				value = strconv.Quote(value)
+			}
+			pairs = append(pairs, f.key+"="+value)
+		}
+		if _, err := fmt.Fprintln(w, strings.Join(pairs, " ")); err != nil {
+			return err
+		}
+	}
+	return nil
+}
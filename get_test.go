@@ -0,0 +1,246 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ljagiello/airdash/airgradient"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteRecords(t *testing.T) {
+	testCases := []struct {
+		name     string
+		format   string
+		expected string
+	}{
+		{
+			"table",
+			"table",
+			"LOCATION  TEMP     HUMIDITY  CO2      PM2.5    TVOC INDEX  NOX INDEX  UPDATED\n" +
+				"Test Loc  75.7 °F  52.0 %    548 ppm  4 µg/m³  100         1          2023-10-10T03:42:11Z\n",
+		},
+		{
+			"json",
+			"json",
+			`[
+  {
+    "locationId": 12345,
+    "locationName": "Test Loc",
+    "serialno": "aabb12",
+    "timestamp": "2023-10-10T03:42:11Z",
+    "stale": false,
+    "temperature": 75.74,
+    "tempUnit": "F",
+    "humidity": 52,
+    "co2": 548,
+    "pm02": 4,
+    "tvoc": 93.979355,
+    "tvocIndex": 100,
+    "noxIndex": 1,
+    "wifi": -58,
+    "dewPoint": 56.87,
+    "heatIndex": 75.46,
+    "humidex": 27.56,
+    "absoluteHumidity": 11.51,
+    "wetBulb": 63.86
+  }
+]
+`,
+		},
+		{
+			"yaml",
+			"yaml",
+			`- locationId: 12345
+  locationName: Test Loc
+  serialno: aabb12
+  timestamp: 2023-10-10T03:42:11Z
+  stale: false
+  temperature: 75.74
+  tempUnit: F
+  humidity: 52
+  co2: 548
+  pm02: 4
+  tvoc: 93.979355
+  tvocIndex: 100
+  noxIndex: 1
+  wifi: -58
+  dewPoint: 56.87
+  heatIndex: 75.46
+  humidex: 27.56
+  absoluteHumidity: 11.51
+  wetBulb: 63.86
+`,
+		},
+		{
+			"csv",
+			"csv",
+			"location_id,location_name,serialno,timestamp,stale,temperature,temp_unit,humidity,co2,pm01,pm02,pm02_raw,pm02_correction,pm10,pm003_count,tvoc,tvoc_index,nox_index,wifi,dew_point,heat_index,humidex,absolute_humidity,wet_bulb\n" +
+				"12345,Test Loc,aabb12,2023-10-10T03:42:11Z,false,75.74,F,52,548,,4,,,,,93.979355,100,1,-58,56.87,75.46,27.56,11.51,63.86\n",
+		},
+		{
+			"kv",
+			"kv",
+			`location_id=12345 location_name="Test Loc" serialno=aabb12 timestamp=2023-10-10T03:42:11Z stale=false temperature=75.74 temp_unit=F humidity=52 co2=548 pm02=4 tvoc=93.979355 tvoc_index=100 nox_index=1 wifi=-58 dew_point=56.87 heat_index=75.46 humidex=27.56 absolute_humidity=11.51 wet_bulb=63.86` + "\n",
+		},
+	}
+
+	measures := airgradient.Measures{
+		LocationID:   12345,
+		LocationName: "Test Loc",
+		Pm02:         airgradient.NewValue(4),
+		Atmp:         airgradient.NewValue(24.3),
+		Rhum:         airgradient.NewValue(52),
+		Rco2:         airgradient.NewValue(548),
+		Tvoc:         airgradient.NewValue(93.979355),
+		Wifi:         airgradient.NewValue(-58),
+		Timestamp:    time.Date(2023, 10, 10, 3, 42, 11, 0, time.UTC),
+		Serialno:     "aabb12",
+		TvocIndex:    airgradient.NewValue(100),
+		NoxIndex:     airgradient.NewValue(1),
+	}
+	record := newMeasureRecord(measures, "F")
+	stale := false
+	record.Stale = &stale
+	records := []measureRecord{record}
+
+	for _, tC := range testCases {
+		t.Run(tC.name, func(t *testing.T) {
+			var out bytes.Buffer
+			require.NoError(t, writeRecords(&out, records, tC.format))
+			assert.Equal(t, tC.expected, out.String())
+		})
+	}
+}
+
+func TestWriteRecordsCorrected(t *testing.T) {
+	m := airgradient.CorrectPM25(airgradient.Measures{
+		LocationID: 12345,
+		Pm02:       airgradient.NewValue(10),
+		Rhum:       airgradient.NewValue(50),
+		Timestamp:  time.Date(2023, 10, 10, 3, 42, 11, 0, time.UTC),
+	})
+	records := []measureRecord{newMeasureRecord(m, "C")}
+
+	testCases := []struct {
+		name     string
+		format   string
+		expected string
+	}{
+		{
+			"table",
+			formatTable,
+			"LOCATION  TEMP  HUMIDITY  CO2  PM2.5          TVOC INDEX  NOX INDEX  UPDATED\n" +
+				"12345     —     50.0 %    —    7 µg/m³ (epa)  —           —          2023-10-10T03:42:11Z\n",
+		},
+		{
+			"kv",
+			formatKV,
+			"location_id=12345 location_name=\"\" serialno=\"\" timestamp=2023-10-10T03:42:11Z temp_unit=C humidity=50 pm02=6.68 pm02_raw=10 pm02_correction=epa\n",
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.name, func(t *testing.T) {
+			var out bytes.Buffer
+			require.NoError(t, writeRecords(&out, records, tC.format))
+			assert.Equal(t, tC.expected, out.String())
+		})
+	}
+}
+
+func TestWriteRecordsStale(t *testing.T) {
+	record := newMeasureRecord(airgradient.Measures{LocationID: 12345, Rco2: airgradient.NewValue(548), Timestamp: time.Date(2023, 10, 10, 3, 42, 11, 0, time.UTC)}, "C")
+	stale := true
+	record.Stale = &stale
+	records := []measureRecord{record}
+
+	testCases := []struct {
+		name     string
+		format   string
+		expected string
+	}{
+		{
+			"table",
+			formatTable,
+			"LOCATION  TEMP  HUMIDITY  CO2      PM2.5  TVOC INDEX  NOX INDEX  UPDATED\n" +
+				"12345     —     —         548 ppm  —      —           —          2023-10-10T03:42:11Z (stale)\n",
+		},
+		{
+			"kv",
+			formatKV,
+			"location_id=12345 location_name=\"\" serialno=\"\" timestamp=2023-10-10T03:42:11Z stale=true temp_unit=C co2=548\n",
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.name, func(t *testing.T) {
+			var out bytes.Buffer
+			require.NoError(t, writeRecords(&out, records, tC.format))
+			assert.Equal(t, tC.expected, out.String())
+		})
+	}
+}
+
+func TestWriteTableMissingValues(t *testing.T) {
+	records := []measureRecord{newMeasureRecord(airgradient.Measures{LocationID: 12345, Rco2: airgradient.NewValue(548)}, "C")}
+
+	var out bytes.Buffer
+	require.NoError(t, writeRecords(&out, records, formatTable))
+	assert.Equal(t,
+		"LOCATION  TEMP  HUMIDITY  CO2      PM2.5  TVOC INDEX  NOX INDEX  UPDATED\n"+
+			"12345     —     —         548 ppm  —      —           —          0001-01-01T00:00:00Z\n",
+		out.String())
+}
+
+func TestWriteRecordsUnknownFormat(t *testing.T) {
+	err := writeRecords(&bytes.Buffer{}, nil, "xml")
+	assert.True(t, errors.Is(err, errUnknownFormat))
+}
+
+func TestRunGet(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		http.ServeFile(w, r, "airgradient/testdata/local-measures-current.json")
+	}))
+	defer server.Close()
+
+	testCases := []struct {
+		name      string
+		locations string
+		args      []string
+		err       error
+		requests  int32
+	}{
+		{"csv", "", []string{"-format", "csv"}, nil, 1},
+		{"unknown-format", "", []string{"-format", "xml"}, errUnknownFormat, 0},
+		{"no-selected-locations", "locations: [Kitchen]\n", nil, airgradient.ErrNotFound, 1},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.name, func(t *testing.T) {
+			requests.Store(0)
+			configPath := filepath.Join(t.TempDir(), "config.yaml")
+			config := "source: local\ndevice:\n  host: " + server.URL + "\n  name: Living Room\n" + tC.locations
+			require.NoError(t, os.WriteFile(configPath, []byte(config), 0o600))
+
+			var buf bytes.Buffer
+			err := runGet(context.Background(), append([]string{"-config", configPath}, tC.args...), &buf)
+			assert.Equal(t, tC.requests, requests.Load())
+			if tC.err != nil {
+				require.ErrorIs(t, err, tC.err)
+				assert.Empty(t, buf.String())
+				return
+			}
+			require.NoError(t, err)
+			assert.Contains(t, buf.String(), "Living Room")
+		})
+	}
+}
@@ -0,0 +1,342 @@
+//go:build darwin
+
+package main
+
+import (
+	"context"
+	_ "embed"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/ljagiello/airdash/airgradient"
+	"github.com/ljagiello/airdash/alert"
+	"github.com/ljagiello/airdash/aqi"
+	"github.com/ljagiello/airdash/config"
+	"github.com/ljagiello/airdash/notify"
+	"github.com/ljagiello/airdash/render"
+	"github.com/progrium/darwinkit/dispatch"
+	"github.com/progrium/darwinkit/helper/action"
+	"github.com/progrium/darwinkit/macos/appkit"
+	"github.com/progrium/darwinkit/macos/foundation"
+	"github.com/progrium/darwinkit/objc"
+)
+
+var aboutWindow objc.Object
+
+//go:embed assets/app/logo.svg
+var logoSVG []byte
+
+func showAboutWindow() {
+	// If window already exists, just bring it to front
+	if !aboutWindow.IsNil() {
+		window := appkit.WindowFrom(aboutWindow.Ptr())
+		window.MakeKeyAndOrderFront(nil)
+		appkit.Application_SharedApplication().ActivateIgnoringOtherApps(true)
+		return
+	}
+
+	// Create window - more compact
+	rect := foundation.Rect{
+		Origin: foundation.Point{X: 0, Y: 0},
+		Size:   foundation.Size{Width: 400, Height: 340},
+	}
+
+	window := appkit.NewWindowWithContentRectStyleMaskBackingDefer(
+		rect,
+		appkit.WindowStyleMaskTitled|appkit.WindowStyleMaskClosable,
+		appkit.BackingStoreBuffered,
+		false,
+	)
+	window.SetTitle("About AirDash")
+	window.SetReleasedWhenClosed(false) // Keep window in memory when closed
+	window.Center()
+
+	// Create content view
+	contentView := window.ContentView()
+
+	// Load SVG logo - smaller size
+	logoImage := appkit.NewImageWithData(logoSVG)
+
+	// Create image view for logo
+	logoView := appkit.NewImageView()
+	logoView.SetImage(logoImage)
+	logoView.SetFrame(foundation.Rect{
+		Origin: foundation.Point{X: 150, Y: 230},
+		Size:   foundation.Size{Width: 100, Height: 100},
+	})
+	contentView.AddSubview(logoView)
+
+	// App name label - centered, larger, bold
+	nameLabel := appkit.NewTextField()
+	nameLabel.SetStringValue("AirDash")
+	nameLabel.SetEditable(false)
+	nameLabel.SetBordered(false)
+	nameLabel.SetDrawsBackground(false)
+	nameLabel.SetFont(appkit.Font_BoldSystemFontOfSize(28))
+	nameLabel.SetAlignment(appkit.TextAlignmentCenter)
+	nameLabel.SetFrame(foundation.Rect{
+		Origin: foundation.Point{X: 0, Y: 185},
+		Size:   foundation.Size{Width: 400, Height: 35},
+	})
+	contentView.AddSubview(nameLabel)
+
+	// Version label - centered, tighter spacing
+	versionLabel := appkit.NewTextField()
+	versionLabel.SetStringValue(fmt.Sprintf("Version  %s", version))
+	versionLabel.SetEditable(false)
+	versionLabel.SetBordered(false)
+	versionLabel.SetDrawsBackground(false)
+	versionLabel.SetFont(appkit.Font_SystemFontOfSize(13))
+	versionLabel.SetAlignment(appkit.TextAlignmentCenter)
+	versionLabel.SetFrame(foundation.Rect{
+		Origin: foundation.Point{X: 0, Y: 140},
+		Size:   foundation.Size{Width: 400, Height: 18},
+	})
+	contentView.AddSubview(versionLabel)
+
+	// Build label - centered, tighter spacing
+	buildLabel := appkit.NewTextField()
+	buildLabel.SetStringValue(fmt.Sprintf("Build  %s", date))
+	buildLabel.SetEditable(false)
+	buildLabel.SetBordered(false)
+	buildLabel.SetDrawsBackground(false)
+	buildLabel.SetFont(appkit.Font_SystemFontOfSize(13))
+	buildLabel.SetAlignment(appkit.TextAlignmentCenter)
+	buildLabel.SetFrame(foundation.Rect{
+		Origin: foundation.Point{X: 0, Y: 120},
+		Size:   foundation.Size{Width: 400, Height: 18},
+	})
+	contentView.AddSubview(buildLabel)
+
+	// Commit label - centered, tighter spacing
+	commitLabel := appkit.NewTextField()
+	commitLabel.SetStringValue(fmt.Sprintf("Commit  %s", commit))
+	commitLabel.SetEditable(false)
+	commitLabel.SetBordered(false)
+	commitLabel.SetDrawsBackground(false)
+	commitLabel.SetFont(appkit.Font_SystemFontOfSize(13))
+	commitLabel.SetAlignment(appkit.TextAlignmentCenter)
+	commitLabel.SetTextColor(appkit.Color_LinkColor())
+	commitLabel.SetFrame(foundation.Rect{
+		Origin: foundation.Point{X: 0, Y: 100},
+		Size:   foundation.Size{Width: 400, Height: 18},
+	})
+	contentView.AddSubview(commitLabel)
+
+	// GitHub button - centered
+	githubButton := appkit.Button_ButtonWithTitleTargetAction("GitHub", nil, objc.Selector{})
+	githubButton.SetBezelStyle(appkit.BezelStyleRounded)
+	githubButton.SetFrame(foundation.Rect{
+		Origin: foundation.Point{X: 150, Y: 40},
+		Size:   foundation.Size{Width: 100, Height: 32},
+	})
+
+	// Set button action to open GitHub URL
+	githubButton.SetTarget(githubButton.Object)
+	githubButton.SetAction(objc.Sel("performAction:"))
+
+	// Use action helper to handle click
+	action.Set(githubButton, func(sender objc.Object) {
+		url := foundation.URL_URLWithString("https://github.com/ljagiello/airdash")
+		appkit.Workspace_SharedWorkspace().OpenURL(url)
+	})
+
+	contentView.AddSubview(githubButton)
+
+	aboutWindow = window.Object
+	objc.Retain(&aboutWindow) // Retain to prevent deallocation
+	window.MakeKeyAndOrderFront(nil)
+	appkit.Application_SharedApplication().ActivateIgnoringOtherApps(true)
+}
+
+// runGUI runs the menu bar app, polling source until ctx is done. Terminating
+// the app exits the process without returning, so polling is stopped and quit
+// is called first, whether from the menu or on SIGTERM.
+func runGUI(ctx context.Context, cfg *config.Config, source airgradient.Source, standard aqi.Standard, alerts *alert.Engine, notifier notify.Notifier, quit func()) {
+	// Create the app manually instead of using RunApp
+	app := appkit.Application_SharedApplication()
+	app.SetActivationPolicy(appkit.ApplicationActivationPolicyAccessory)
+
+	ctx, cancel := context.WithCancel(ctx)
+	var polling sync.WaitGroup
+	// terminate runs on the main thread and leaves the wait for the poller and
+	// the outputs to the background, so the menu stays responsive
+	terminate := func() {
+		cancel()
+		go func() {
+			polling.Wait()
+			quit()
+			dispatch.MainQueue().DispatchAsync(func() {
+				app.Terminate(nil)
+			})
+		}()
+	}
+
+	// launchd stops the agent with SIGTERM
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-signals
+		dispatch.MainQueue().DispatchAsync(terminate)
+	}()
+
+	// Schedule UI setup to run on main queue after app.Run() starts
+	dispatch.MainQueue().DispatchAsync(func() {
+		// Auto-install LaunchAgent silently on first launch
+		if !isDaemonInstalled() {
+			logger.Info("First launch detected - installing LaunchAgent")
+			if err := installDaemon(); err != nil {
+				// Log error but continue running in GUI mode
+				logger.Error("Failed to install LaunchAgent - running in GUI mode only", "error", err)
+			} else {
+				logger.Info("LaunchAgent installed successfully - exiting to let launchd start")
+				// Success - quit and let launchd start
+				terminate()
+				return
+			}
+		}
+
+		item := appkit.StatusBar_SystemStatusBar().StatusItemWithLength(-1)
+		objc.Retain(&item)
+
+		// Create About menu item with callback
+		itemAbout := appkit.NewMenuItemWithAction("About AirDash", "", func(sender objc.Object) {
+			showAboutWindow()
+		})
+
+		// Create Quit menu item, closing the outputs before terminating
+		itemQuit := appkit.NewMenuItemWithAction("Quit", "", func(sender objc.Object) {
+			terminate()
+		})
+
+		// Build menu
+		menu := appkit.NewMenu()
+		menu.AddItem(itemAbout)
+		menu.AddItem(appkit.MenuItem_SeparatorItem())
+		menu.AddItem(itemQuit)
+		item.SetMenu(menu)
+
+		// Alert, AQI, comfort and per-location breakdown items shown above About, only touched on the main thread
+		var locationItems []appkit.MenuItem
+		setLocationItems := func(lines []string) {
+			for _, locationItem := range locationItems {
+				menu.RemoveItem(locationItem)
+			}
+			locationItems = locationItems[:0]
+			if len(lines) == 0 {
+				return
+			}
+			for i, line := range lines {
+				locationItem := appkit.NewMenuItem()
+				locationItem.SetTitle(line)
+				menu.InsertItemAtIndex(locationItem, i)
+				locationItems = append(locationItems, locationItem)
+			}
+			separator := appkit.MenuItem_SeparatorItem()
+			menu.InsertItemAtIndex(separator, len(lines))
+			locationItems = append(locationItems, separator)
+		}
+
+		// Replace the old numbers so a failure is visible in the menu bar
+		showError := func(err error) {
+			dispatch.MainQueue().DispatchAsync(func() {
+				item.Button().SetTitle(render.FormatErrorTitle(err))
+				setLocationItems(nil)
+			})
+		}
+
+		// The AQI NowCast follows the title's readings, only touched by the polling goroutine
+		var tracker aqi.Tracker
+
+		// Already checked when the config was loaded
+		titleTemplate, err := render.ParseTitleTemplate(cfg.TitleTemplate)
+		if err != nil {
+			logger.Error("Parsing titleTemplate", "error", err)
+		}
+		levels, err := newLevels(cfg)
+		if err != nil {
+			logger.Error("Parsing levels", "error", err)
+		}
+
+		updateStatus := func(measures []airgradient.Measures, err error) {
+			if err != nil {
+				logger.Error("Fetching measures", "error", err, "state", render.ErrorState(err))
+				notifyAlerts(notifier, alerts.Failed())
+				showError(err)
+				return
+			}
+			logger.Debug("AirGradientMeasures", "measures", measures)
+
+			notifyAlerts(notifier, alerts.Evaluate(measures))
+
+			selected := airgradient.SelectLocations(measures, cfg.Locations)
+			if len(selected) == 0 {
+				logger.Error("No measures for the configured locations", "locations", cfg.Locations)
+				showError(airgradient.ErrNotFound)
+				return
+			}
+			opts := render.Options{Aggregate: cfg.Aggregate, TempUnit: cfg.TempUnit, AQI: cfg.AQI, Standard: standard, Comfort: cfg.Comfort, Title: titleTemplate, Levels: levels, MaxAge: cfg.StaleAfter()}
+			// Only locations that are online move the NowCast on
+			fresh := render.FreshMeasures(selected, opts)
+			if len(fresh) > 0 {
+				tracker.Add(render.AggregateMeasures(fresh, cfg.Aggregate))
+			}
+			if index, ok := tracker.Index(time.Now(), standard); ok {
+				opts.TitleAQI = &index
+			}
+			view := render.BuildStatusView(selected, opts)
+			// Stale readings are left in the system colour
+			var tint string
+			if !view.Stale {
+				tint = levels.Worst(fresh).Color()
+			}
+
+			var lines []string
+			for _, event := range alerts.Active() {
+				lines = append(lines, "🔔 "+event.String())
+			}
+			for _, line := range []string{view.AQI, view.Comfort} {
+				if line != "" {
+					lines = append(lines, line)
+				}
+			}
+			lines = append(lines, view.Locations...)
+
+			// updates to the ui should happen on the main thread to avoid segfaults
+			dispatch.MainQueue().DispatchAsync(func() {
+				setTitle(item.Button(), view.Title, tint)
+				setLocationItems(lines)
+			})
+		}
+
+		// Quitting before the menu was set up has nothing to wait for
+		if ctx.Err() != nil {
+			return
+		}
+		polling.Add(1)
+		go func() {
+			defer polling.Done()
+			poll(ctx, source, time.Duration(cfg.Interval)*time.Second, updateStatus)
+		}()
+	})
+
+	app.Run()
+}
+
+// setTitle shows title in the menu bar tinted with color, e.g. "#FF7E00",
+// or in the system colour when color is empty.
+func setTitle(button appkit.StatusBarButton, title, color string) {
+	var r, g, b uint8
+	if _, err := fmt.Sscanf(color, "#%02x%02x%02x", &r, &g, &b); err != nil {
+		button.SetTitle(title)
+		return
+	}
+	tint := appkit.Color_ColorWithSRGBRedGreenBlueAlpha(float64(r)/255, float64(g)/255, float64(b)/255, 1)
+	// "NSColor" is NSForegroundColorAttributeName
+	button.SetAttributedTitle(foundation.NewAttributedStringWithStringAttributes(title,
+		map[foundation.AttributedStringKey]objc.IObject{"NSColor": tint}))
+}
@@ -0,0 +1,21 @@
+//go:build !darwin
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/ljagiello/airdash/airgradient"
+	"github.com/ljagiello/airdash/alert"
+	"github.com/ljagiello/airdash/aqi"
+	"github.com/ljagiello/airdash/config"
+	"github.com/ljagiello/airdash/notify"
+)
+
+// runGUI is only available on macOS; other platforms use the headless subcommands.
+func runGUI(ctx context.Context, cfg *config.Config, source airgradient.Source, standard aqi.Standard, alerts *alert.Engine, notifier notify.Notifier, quit func()) {
+	fmt.Fprintf(os.Stderr, "Error: the menu bar app is only available on macOS\nUse 'airdash get' to print the current measures\n")
+	os.Exit(1)
+}
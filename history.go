@@ -0,0 +1,152 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/ljagiello/airdash/airgradient"
+	"github.com/ljagiello/airdash/config"
+	"github.com/ljagiello/airdash/render"
+	"github.com/ljagiello/airdash/stats"
+	"github.com/ljagiello/airdash/store"
+	"gopkg.in/yaml.v3"
+)
+
+// runHistory implements the history subcommand: it prints the past measures
+// of a location, or summary statistics of them with -summary. Measures come
+// from the cloud API, or from the history store with -store or when reading
+// from a local device. The options are applied to the API client after the
+// configured ones.
+func runHistory(ctx context.Context, args []string, w io.Writer, opts ...airgradient.Option) error {
+	fs := flag.NewFlagSet("history", flag.ContinueOnError)
+	configPath := fs.String("config", getDefaultConfigPath(), "path to config file")
+	locationID := fs.Int("location", 0, "location ID (default: locationId from the config)")
+	since := fs.Duration("since", 24*time.Hour, "how far back to fetch measures")
+	format := fs.String("format", formatTable, "output format: table, json, yaml, csv or kv")
+	summary := fs.Bool("summary", false, "print min/max/mean/percentiles per metric instead of the series")
+	fromStore := fs.Bool("store", false, "read from the local history store instead of the cloud API")
+	hourly := fs.Bool("hourly", false, "average the measures per hour (history store only)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *since <= 0 {
+		return fmt.Errorf("-since must be positive, got %s", *since)
+	}
+
+	cfg, err := loadConfig(*configPath)
+	if err != nil {
+		return fmt.Errorf("loading config %s: %w", *configPath, err)
+	}
+	// A local device has no cloud history, only what AirDash stored
+	local := cfg.Source == config.SourceLocal
+	if local {
+		*fromStore = true
+	}
+	if *hourly && !*fromStore {
+		return errors.New("-hourly needs the history store, pass -store")
+	}
+	if *fromStore && !cfg.History.Enabled {
+		return errors.New("the history store is off, set history.enabled in the config")
+	}
+	if *locationID == 0 {
+		*locationID = cfg.LocationID
+	}
+	// Measures read through a local API are stored under location 0
+	if *locationID == 0 && !local {
+		return errors.New("no location: pass -location or set locationId in the config")
+	}
+
+	to := time.Now()
+	var series []airgradient.Measures
+	if *fromStore {
+		series, err = queryStore(ctx, cfg, store.Query{LocationID: *locationID, From: to.Add(-*since), To: to, Hourly: *hourly})
+		if err != nil {
+			return err
+		}
+	} else {
+		client := newClient(cfg, opts...)
+		series, err = client.History(ctx, *locationID, to.Add(-*since), to)
+		if err != nil {
+			return fmt.Errorf("fetching history (%s): %w", render.ErrorState(err), err)
+		}
+		if cfg.PM25Correction == airgradient.CorrectionEPA {
+			for i, m := range series {
+				series[i] = airgradient.CorrectPM25(m)
+			}
+		}
+	}
+
+	if *summary {
+		return writeSummaries(w, summarize(series, cfg.TempUnit), *format)
+	}
+
+	records := make([]measureRecord, 0, len(series))
+	for _, m := range series {
+		records = append(records, newMeasureRecord(m, cfg.TempUnit))
+	}
+	return writeRecords(w, records, *format)
+}
+
+// queryStore reads measures from the history store. They were stored as
+// shown, with any PM2.5 correction already applied.
+func queryStore(ctx context.Context, cfg *config.Config, q store.Query) ([]airgradient.Measures, error) {
+	s, err := openStore(cfg)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = s.Close() }()
+	return s.Query(ctx, q)
+}
+
+// summarize computes the statistics of every metric of the series, with
+// temperatures in the configured unit.
+func summarize(series []airgradient.Measures, tempUnit string) []stats.Summary {
+	return stats.Summarize(series, render.ConvertMetrics(airgradient.Metrics, tempUnit))
+}
+
+// writeSummaries writes the statistics in the requested format.
+func writeSummaries(w io.Writer, summaries []stats.Summary, format string) error {
+	switch format {
+	case formatTable:
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		_, _ = fmt.Fprintln(tw, "METRIC\tUNIT\tCOUNT\tMIN\tMAX\tMEAN\tP50\tP90\tP95")
+		for _, s := range summaries {
+			_, _ = fmt.Fprintf(tw, "%s\t%s\t%d\t%.1f\t%.1f\t%.1f\t%.1f\t%.1f\t%.1f\n",
+				s.Metric, s.Unit, s.Count, s.Min, s.Max, s.Mean, s.P50, s.P90, s.P95)
+		}
+		return tw.Flush()
+	case formatJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(summaries)
+	case formatYAML:
+		enc := yaml.NewEncoder(w)
+		enc.SetIndent(2)
+		if err := enc.Encode(summaries); err != nil {
+			return err
+		}
+		return enc.Close()
+	case formatCSV:
+		cw := csv.NewWriter(w)
+		_ = cw.Write([]string{"metric", "unit", "count", "min", "max", "mean", "p50", "p90", "p95"})
+		num := func(f float64) string { return strconv.FormatFloat(f, 'f', -1, 64) }
+		for _, s := range summaries {
+			_ = cw.Write([]string{
+				s.Metric, s.Unit, strconv.Itoa(s.Count),
+				num(s.Min), num(s.Max), num(s.Mean), num(s.P50), num(s.P90), num(s.P95),
+			})
+		}
+		cw.Flush()
+		return cw.Error()
+	default:
+		return fmt.Errorf("%w: %q for -summary, expected table, json, yaml or csv", errUnknownFormat, format)
+	}
+}
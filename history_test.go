@@ -0,0 +1,121 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ljagiello/airdash/airgradient"
+	"github.com/ljagiello/airdash/stats"
+	"github.com/ljagiello/airdash/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunHistory(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/locations/12345/measures/past", r.URL.Path)
+		http.ServeFile(w, r, "airgradient/testdata/api-v1-locations-12345-measures-past.json")
+	}))
+	defer server.Close()
+
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(configPath, []byte("token: SECRET-TOKEN\nlocationId: 12345\ntempUnit: F\n"), 0o600))
+
+	testCases := []struct {
+		name     string
+		args     []string
+		expected []string
+	}{
+		{
+			"series-csv",
+			[]string{"-format", "csv"},
+			[]string{
+				"location_id,location_name,serialno,timestamp,temperature,temp_unit,humidity,co2,pm01,pm02,pm02_raw,pm02_correction,pm10,pm003_count,tvoc,tvoc_index,nox_index,wifi,dew_point,heat_index,humidex,absolute_humidity,wet_bulb\n",
+				"2023-10-10T02:00:00Z",
+			},
+		},
+		{
+			"summary-csv",
+			[]string{"-summary", "-format", "csv"},
+			[]string{
+				"metric,unit,count,min,max,mean,p50,p90,p95\n",
+				"pm02,µg/m³,3,4,9,",
+			},
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			args := append([]string{"-config", configPath}, tC.args...)
+			require.NoError(t, runHistory(context.Background(), args, &buf, airgradient.WithBaseURL(server.URL)))
+			for _, expected := range tC.expected {
+				assert.Contains(t, buf.String(), expected)
+			}
+		})
+	}
+}
+
+func TestRunHistoryFromStore(t *testing.T) {
+	dir := t.TempDir()
+	storePath := filepath.Join(dir, "history.db")
+	s, err := store.Open(store.Config{Path: storePath})
+	require.NoError(t, err)
+	now := time.Now().Truncate(time.Hour)
+	_, err = s.Insert(context.Background(), []airgradient.Measures{
+		{Serialno: "aabb12", Pm02: airgradient.NewValue(4), Timestamp: now.Add(-50 * time.Minute)},
+		{Serialno: "aabb12", Pm02: airgradient.NewValue(8), Timestamp: now.Add(-40 * time.Minute)},
+		{Serialno: "aabb12", Pm02: airgradient.NewValue(30), Timestamp: now.Add(-48 * time.Hour)},
+	})
+	require.NoError(t, err)
+	require.NoError(t, s.Close())
+
+	configPath := filepath.Join(dir, "config.yaml")
+	require.NoError(t, os.WriteFile(configPath, []byte("source: local\ndevice:\n  host: airgradient_aabb12.local\nhistory:\n  enabled: true\n  path: "+storePath+"\n"), 0o600))
+
+	testCases := []struct {
+		name     string
+		args     []string
+		expected string
+	}{
+		{"series", nil, "pm02,µg/m³,2,4,8,6,"},
+		{"hourly", []string{"-hourly"}, "pm02,µg/m³,1,6,6,6,"},
+		{"since", []string{"-since", "72h"}, "pm02,µg/m³,3,4,30,14,"},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			args := append([]string{"-config", configPath, "-summary", "-format", "csv"}, tC.args...)
+			require.NoError(t, runHistory(context.Background(), args, &buf))
+			assert.Contains(t, buf.String(), tC.expected)
+		})
+	}
+}
+
+func TestSummarizeTemperatureUnit(t *testing.T) {
+	v := airgradient.NewValue
+	series := []airgradient.Measures{{Atmp: v(20), Rhum: v(50)}, {Atmp: v(30), Rhum: v(50)}}
+
+	summaries := make(map[string]stats.Summary)
+	for _, s := range summarize(series, "F") {
+		summaries[s.Metric] = s
+	}
+	assert.Equal(t, "°F", summaries["atmp"].Unit)
+	assert.InDelta(t, 68.0, summaries["atmp"].Min, 1e-9)
+	assert.InDelta(t, 86.0, summaries["atmp"].Max, 1e-9)
+
+	// Derived temperatures are computed in °C before they are converted
+	assert.Equal(t, "°F", summaries["dewPoint"].Unit)
+	assert.InDelta(t, 48.67, summaries["dewPoint"].Min, 0.01)
+	assert.Equal(t, "g/m³", summaries["absoluteHumidity"].Unit)
+}
+
+func TestWriteSummariesUnknownFormat(t *testing.T) {
+	err := writeSummaries(&bytes.Buffer{}, []stats.Summary{}, formatKV)
+	assert.ErrorIs(t, err, errUnknownFormat)
+}
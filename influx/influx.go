@@ -0,0 +1,274 @@
+// Package influx writes measures to InfluxDB 2.x or 3.x in line protocol.
+package influx
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ljagiello/airdash/airgradient"
+)
+
+const (
+	// Measurement is the measurement every point is written to.
+	Measurement = "airgradient"
+	// DefaultBatchSize is the number of lines sent per write request.
+	DefaultBatchSize = 5000
+	// DefaultMaxBuffer is the number of lines kept while InfluxDB is
+	// unreachable. The oldest lines are dropped beyond it.
+	DefaultMaxBuffer = 100000
+	// DefaultTimeout bounds each write request.
+	DefaultTimeout = 10 * time.Second
+)
+
+// ErrInvalidConfig is returned by NewWriter for a database it cannot write
+// to.
+var ErrInvalidConfig = errors.New("invalid InfluxDB config")
+
+// Config is an InfluxDB database as configured in config.yaml:
+//
+//	influxdb:
+//	  url: http://localhost:8086
+//	  token: my-token
+//	  org: home
+//	  bucket: airgradient
+type Config struct {
+	// URL is the base URL of the InfluxDB server.
+	URL string `yaml:"url"`
+	// Token authenticates every write.
+	Token string `yaml:"token"`
+	// Org is the InfluxDB 2.x organization, unused by InfluxDB 3.x.
+	Org string `yaml:"org"`
+	// Bucket is the InfluxDB 2.x bucket or the InfluxDB 3.x database.
+	Bucket string `yaml:"bucket"`
+	// BatchSize is the number of lines per write, DefaultBatchSize by
+	// default.
+	BatchSize int `yaml:"batchSize"`
+	// MaxBuffer is the number of lines kept while writes fail,
+	// DefaultMaxBuffer by default.
+	MaxBuffer int `yaml:"maxBuffer"`
+	// Timeout bounds each write, DefaultTimeout by default.
+	Timeout time.Duration `yaml:"timeout"`
+}
+
+// Writer writes measures to InfluxDB through its /api/v2/write endpoint,
+// which both InfluxDB 2.x and 3.x serve. Lines that cannot be written
+// because InfluxDB is unreachable, rate limiting or failing are buffered
+// and sent with the next write. It is safe for concurrent use.
+type Writer struct {
+	config     Config
+	writeURL   string
+	httpClient *http.Client
+
+	mu     sync.Mutex
+	buffer []string
+}
+
+// Option configures a Writer.
+type Option func(*Writer)
+
+// WithHTTPClient sets the HTTP client used for writes.
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(w *Writer) {
+		w.httpClient = httpClient
+	}
+}
+
+// NewWriter returns a writer for the database, or an error wrapping
+// ErrInvalidConfig if its URL or bucket is invalid.
+func NewWriter(config Config, opts ...Option) (*Writer, error) {
+	if config.BatchSize <= 0 {
+		config.BatchSize = DefaultBatchSize
+	}
+	if config.MaxBuffer <= 0 {
+		config.MaxBuffer = DefaultMaxBuffer
+	}
+	if config.Timeout == 0 {
+		config.Timeout = DefaultTimeout
+	}
+
+	u, err := url.Parse(config.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("%w: URL %q must be an http or https URL", ErrInvalidConfig, config.URL)
+	}
+	if config.Bucket == "" {
+		return nil, fmt.Errorf("%w: bucket is required", ErrInvalidConfig)
+	}
+	query := url.Values{"bucket": {config.Bucket}, "precision": {"s"}}
+	if config.Org != "" {
+		query.Set("org", config.Org)
+	}
+	u = u.JoinPath("api", "v2", "write")
+	u.RawQuery = query.Encode()
+
+	w := &Writer{
+		config:     config,
+		writeURL:   u.String(),
+		httpClient: &http.Client{Timeout: config.Timeout},
+	}
+	for _, opt := range opts {
+		opt(w)
+	}
+	return w, nil
+}
+
+// Publish writes the measures together with any lines buffered by earlier
+// failures, in batches of Config.BatchSize.
+func (w *Writer) Publish(ctx context.Context, measures []airgradient.Measures) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	for _, m := range measures {
+		if line, ok := Line(m); ok {
+			w.buffer = append(w.buffer, line)
+		}
+	}
+	if over := len(w.buffer) - w.config.MaxBuffer; over > 0 {
+		w.buffer = w.buffer[over:]
+	}
+	return w.flush(ctx)
+}
+
+// Close writes any buffered lines.
+func (w *Writer) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if len(w.buffer) == 0 {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), w.config.Timeout)
+	defer cancel()
+	return w.flush(ctx)
+}
+
+// flush sends the buffer batch by batch. A batch that failed for a
+// transient reason stays buffered, one InfluxDB rejected is dropped.
+func (w *Writer) flush(ctx context.Context) error {
+	for len(w.buffer) > 0 {
+		n := min(len(w.buffer), w.config.BatchSize)
+		err := w.write(ctx, w.buffer[:n])
+		if err != nil && isRetryable(err) {
+			return err
+		}
+		w.buffer = w.buffer[n:]
+		if err != nil {
+			return fmt.Errorf("dropped %d lines: %w", n, err)
+		}
+	}
+	w.buffer = nil
+	return nil
+}
+
+// write sends a single batch.
+func (w *Writer) write(ctx context.Context, lines []string) error {
+	body := strings.Join(lines, "\n") + "\n"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.writeURL, strings.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("creating InfluxDB request: %w", err)
+	}
+	req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+	req.Header.Set("User-Agent", airgradient.DefaultUserAgent)
+	if w.config.Token != "" {
+		req.Header.Set("Authorization", "Token "+w.config.Token)
+	}
+
+	resp, err := w.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("writing to InfluxDB: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		snippet, _ := io.ReadAll(io.LimitReader(resp.Body, 200))
+		return &StatusError{StatusCode: resp.StatusCode, Body: string(bytes.TrimSpace(snippet))}
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	return nil
+}
+
+// StatusError is a write response outside the 2xx range.
+type StatusError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *StatusError) Error() string {
+	msg := fmt.Sprintf("HTTP %d from InfluxDB", e.StatusCode)
+	if e.Body != "" {
+		msg += ": " + e.Body
+	}
+	return msg
+}
+
+// isRetryable reports whether a failed write should stay buffered: the
+// server was unreachable, rate limiting or failing, rather than rejecting
+// the data.
+func isRetryable(err error) bool {
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.StatusCode == http.StatusTooManyRequests || statusErr.StatusCode >= 500
+	}
+	var urlErr *url.Error
+	return errors.As(err, &urlErr)
+}
+
+// Line converts measures into a line protocol point of Measurement, tagged
+// with the location, location ID, serial number and firmware, with a field
+// per present reading and derived metric, temperatures in °C, and the time
+// the measures were taken at in seconds. It reports false when no reading
+// is present.
+func Line(m airgradient.Measures) (string, bool) {
+	var b strings.Builder
+	b.WriteString(Measurement)
+	tag := func(key, value string) {
+		if value != "" {
+			b.WriteString("," + key + "=" + escape(value))
+		}
+	}
+	// Tags are sorted by key, as InfluxDB prefers
+	tag("firmware", m.FirmwareVersion)
+	tag("location", m.LocationName)
+	if m.LocationID != 0 {
+		tag("location_id", strconv.Itoa(m.LocationID))
+	}
+	tag("serial", m.Serialno)
+
+	fields := 0
+	field := func(key string, v airgradient.Value) {
+		if !v.Valid || math.IsNaN(v.Float64) || math.IsInf(v.Float64, 0) {
+			return
+		}
+		sep := ","
+		if fields == 0 {
+			sep = " "
+		}
+		b.WriteString(sep + escape(key) + "=" + strconv.FormatFloat(v.Float64, 'f', -1, 64))
+		fields++
+	}
+	for _, metric := range airgradient.Metrics {
+		field(metric.Name, metric.Value(m))
+	}
+	field("pm02Raw", m.Pm02Raw)
+	if fields == 0 {
+		return "", false
+	}
+
+	if !m.Timestamp.IsZero() {
+		b.WriteString(" " + strconv.FormatInt(m.Timestamp.Unix(), 10))
+	}
+	return b.String(), true
+}
+
+// escape escapes a tag key, tag value or field key.
+func escape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, ",", `\,`, "=", `\=`, " ", `\ `, "\n", `\ `).Replace(s)
+}
@@ -0,0 +1,178 @@
+package influx
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ljagiello/airdash/airgradient"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testMeasures = airgradient.Measures{
+	LocationID:      12345,
+	LocationName:    "Test Loc",
+	Serialno:        "aabb12",
+	FirmwareVersion: "3.1.1",
+	Pm02:            airgradient.NewValue(4),
+	Rco2:            airgradient.NewValue(548),
+	TvocIndex:       airgradient.NewValue(100),
+	Timestamp:       time.Date(2023, 10, 10, 3, 42, 11, 0, time.UTC),
+}
+
+func TestLine(t *testing.T) {
+	testCases := []struct {
+		name     string
+		measures airgradient.Measures
+		expected string
+	}{
+		{
+			"measures",
+			testMeasures,
+			"airgradient,firmware=3.1.1,location=Test\\ Loc,location_id=12345,serial=aabb12 pm02=4,rco2=548,tvocIndex=100 1696909331",
+		},
+		{
+			"escaped-tags",
+			airgradient.Measures{LocationName: "Office, 2nd=floor", Serialno: "ccdd34", Rco2: airgradient.NewValue(1210.5)},
+			"airgradient,location=Office\\,\\ 2nd\\=floor,serial=ccdd34 rco2=1210.5",
+		},
+		{
+			"corrected",
+			airgradient.Measures{Serialno: "ccdd34", Pm02: airgradient.NewValue(3.2), Pm02Raw: airgradient.NewValue(4), Pm02Correction: airgradient.CorrectionEPA},
+			"airgradient,serial=ccdd34 pm02=3.2,pm02Raw=4",
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.name, func(t *testing.T) {
+			line, ok := Line(tC.measures)
+			require.True(t, ok)
+			assert.Equal(t, tC.expected, line)
+		})
+	}
+
+	_, ok := Line(airgradient.Measures{LocationID: 12345, Serialno: "aabb12"})
+	assert.False(t, ok, "measures without readings have no fields")
+}
+
+// fakeInflux is a write endpoint that records the bodies it accepts and
+// replies with the given status codes in turn, then 204.
+type fakeInflux struct {
+	mu       sync.Mutex
+	statuses []int
+	requests []*http.Request
+	bodies   []string
+}
+
+func (f *fakeInflux) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if len(f.statuses) > 0 {
+		status := f.statuses[0]
+		f.statuses = f.statuses[1:]
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(`{"code":"unavailable","message":"try again"}`))
+		return
+	}
+	f.requests = append(f.requests, r)
+	f.bodies = append(f.bodies, string(body))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func newFakeInflux(t *testing.T, statuses ...int) (*fakeInflux, string) {
+	t.Helper()
+	fake := &fakeInflux{statuses: statuses}
+	srv := httptest.NewServer(fake)
+	t.Cleanup(srv.Close)
+	return fake, srv.URL
+}
+
+func TestPublish(t *testing.T) {
+	fake, url := newFakeInflux(t)
+	w, err := NewWriter(Config{URL: url, Token: "SECRET", Org: "home", Bucket: "air"})
+	require.NoError(t, err)
+
+	require.NoError(t, w.Publish(context.Background(), []airgradient.Measures{testMeasures}))
+	require.Len(t, fake.requests, 1)
+	req := fake.requests[0]
+	assert.Equal(t, "/api/v2/write", req.URL.Path)
+	assert.Equal(t, "home", req.URL.Query().Get("org"))
+	assert.Equal(t, "air", req.URL.Query().Get("bucket"))
+	assert.Equal(t, "s", req.URL.Query().Get("precision"))
+	assert.Equal(t, "Token SECRET", req.Header.Get("Authorization"))
+	line, _ := Line(testMeasures)
+	assert.Equal(t, line+"\n", fake.bodies[0])
+}
+
+func TestPublishBatches(t *testing.T) {
+	fake, url := newFakeInflux(t)
+	w, err := NewWriter(Config{URL: url, Bucket: "air", BatchSize: 2})
+	require.NoError(t, err)
+
+	measures := []airgradient.Measures{testMeasures, testMeasures, testMeasures}
+	require.NoError(t, w.Publish(context.Background(), measures))
+	require.Len(t, fake.bodies, 2)
+	assert.Equal(t, 2, strings.Count(fake.bodies[0], "\n"))
+	assert.Equal(t, 1, strings.Count(fake.bodies[1], "\n"))
+}
+
+func TestPublishBuffersOnFailure(t *testing.T) {
+	fake, url := newFakeInflux(t, http.StatusServiceUnavailable)
+	w, err := NewWriter(Config{URL: url, Bucket: "air"})
+	require.NoError(t, err)
+
+	first := testMeasures
+	second := testMeasures
+	second.Timestamp = second.Timestamp.Add(time.Minute)
+
+	err = w.Publish(context.Background(), []airgradient.Measures{first})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "HTTP 503 from InfluxDB")
+	assert.Empty(t, fake.bodies)
+
+	// The buffered line is written ahead of the new one
+	require.NoError(t, w.Publish(context.Background(), []airgradient.Measures{second}))
+	require.Len(t, fake.bodies, 1)
+	firstLine, _ := Line(first)
+	secondLine, _ := Line(second)
+	assert.Equal(t, firstLine+"\n"+secondLine+"\n", fake.bodies[0])
+	assert.Empty(t, w.buffer)
+}
+
+func TestPublishDropsRejectedLines(t *testing.T) {
+	fake, url := newFakeInflux(t, http.StatusBadRequest)
+	w, err := NewWriter(Config{URL: url, Bucket: "air"})
+	require.NoError(t, err)
+
+	err = w.Publish(context.Background(), []airgradient.Measures{testMeasures})
+	require.ErrorContains(t, err, "dropped 1 lines: HTTP 400 from InfluxDB")
+	assert.Empty(t, w.buffer)
+	assert.Empty(t, fake.bodies)
+}
+
+func TestPublishBufferLimit(t *testing.T) {
+	_, url := newFakeInflux(t, http.StatusServiceUnavailable, http.StatusServiceUnavailable, http.StatusServiceUnavailable)
+	w, err := NewWriter(Config{URL: url, Bucket: "air", MaxBuffer: 2})
+	require.NoError(t, err)
+
+	for i := range 3 {
+		m := testMeasures
+		m.Timestamp = m.Timestamp.Add(time.Duration(i) * time.Minute)
+		require.Error(t, w.Publish(context.Background(), []airgradient.Measures{m}))
+	}
+	require.Len(t, w.buffer, 2)
+	assert.True(t, strings.HasSuffix(w.buffer[0], " 1696909391"), "the oldest line is dropped")
+}
+
+func TestNewWriterInvalid(t *testing.T) {
+	_, err := NewWriter(Config{URL: "localhost:8086", Bucket: "air"})
+	require.ErrorIs(t, err, ErrInvalidConfig)
+	_, err = NewWriter(Config{URL: "http://localhost:8086"})
+	require.ErrorIs(t, err, ErrInvalidConfig)
+}
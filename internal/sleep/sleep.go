@@ -0,0 +1,20 @@
+// Package sleep waits for a delay unless a context is done first, e.g.
+// between retries.
+package sleep
+
+import (
+	"context"
+	"time"
+)
+
+// Context waits for d or until ctx is done, returning ctx.Err() in that case.
+func Context(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
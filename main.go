@@ -1,17 +1,21 @@
 package main
 
 import (
-	_ "embed"
+	"context"
+	"errors"
 	"flag"
 	"fmt"
+	"math"
 	"os"
-	"time"
-
-	"github.com/progrium/darwinkit/dispatch"
-	"github.com/progrium/darwinkit/helper/action"
-	"github.com/progrium/darwinkit/macos/appkit"
-	"github.com/progrium/darwinkit/macos/foundation"
-	"github.com/progrium/darwinkit/objc"
+	"os/signal"
+
+	"github.com/ljagiello/airdash/airgradient"
+	"github.com/ljagiello/airdash/alert"
+	"github.com/ljagiello/airdash/aqi"
+	"github.com/ljagiello/airdash/classify"
+	"github.com/ljagiello/airdash/config"
+	"github.com/ljagiello/airdash/notify"
+	"github.com/ljagiello/airdash/render"
 )
 
 var (
@@ -20,138 +24,58 @@ var (
 	date    = "unknown"
 )
 
-var aboutWindow objc.Object
-
-//go:embed assets/app/logo.svg
-var logoSVG []byte
-
-func showAboutWindow() {
-	// If window already exists, just bring it to front
-	if !aboutWindow.IsNil() {
-		window := appkit.WindowFrom(aboutWindow.Ptr())
-		window.MakeKeyAndOrderFront(nil)
-		appkit.Application_SharedApplication().ActivateIgnoringOtherApps(true)
-		return
-	}
-
-	// Create window - more compact
-	rect := foundation.Rect{
-		Origin: foundation.Point{X: 0, Y: 0},
-		Size:   foundation.Size{Width: 400, Height: 340},
-	}
-
-	window := appkit.NewWindowWithContentRectStyleMaskBackingDefer(
-		rect,
-		appkit.WindowStyleMaskTitled|appkit.WindowStyleMaskClosable,
-		appkit.BackingStoreBuffered,
-		false,
-	)
-	window.SetTitle("About AirDash")
-	window.SetReleasedWhenClosed(false) // Keep window in memory when closed
-	window.Center()
-
-	// Create content view
-	contentView := window.ContentView()
-
-	// Load SVG logo - smaller size
-	logoImage := appkit.NewImageWithData(logoSVG)
-
-	// Create image view for logo
-	logoView := appkit.NewImageView()
-	logoView.SetImage(logoImage)
-	logoView.SetFrame(foundation.Rect{
-		Origin: foundation.Point{X: 150, Y: 230},
-		Size:   foundation.Size{Width: 100, Height: 100},
-	})
-	contentView.AddSubview(logoView)
-
-	// App name label - centered, larger, bold
-	nameLabel := appkit.NewTextField()
-	nameLabel.SetStringValue("AirDash")
-	nameLabel.SetEditable(false)
-	nameLabel.SetBordered(false)
-	nameLabel.SetDrawsBackground(false)
-	nameLabel.SetFont(appkit.Font_BoldSystemFontOfSize(28))
-	nameLabel.SetAlignment(appkit.TextAlignmentCenter)
-	nameLabel.SetFrame(foundation.Rect{
-		Origin: foundation.Point{X: 0, Y: 185},
-		Size:   foundation.Size{Width: 400, Height: 35},
-	})
-	contentView.AddSubview(nameLabel)
-
-	// Version label - centered, tighter spacing
-	versionLabel := appkit.NewTextField()
-	versionLabel.SetStringValue(fmt.Sprintf("Version  %s", version))
-	versionLabel.SetEditable(false)
-	versionLabel.SetBordered(false)
-	versionLabel.SetDrawsBackground(false)
-	versionLabel.SetFont(appkit.Font_SystemFontOfSize(13))
-	versionLabel.SetAlignment(appkit.TextAlignmentCenter)
-	versionLabel.SetFrame(foundation.Rect{
-		Origin: foundation.Point{X: 0, Y: 140},
-		Size:   foundation.Size{Width: 400, Height: 18},
-	})
-	contentView.AddSubview(versionLabel)
-
-	// Build label - centered, tighter spacing
-	buildLabel := appkit.NewTextField()
-	buildLabel.SetStringValue(fmt.Sprintf("Build  %s", date))
-	buildLabel.SetEditable(false)
-	buildLabel.SetBordered(false)
-	buildLabel.SetDrawsBackground(false)
-	buildLabel.SetFont(appkit.Font_SystemFontOfSize(13))
-	buildLabel.SetAlignment(appkit.TextAlignmentCenter)
-	buildLabel.SetFrame(foundation.Rect{
-		Origin: foundation.Point{X: 0, Y: 120},
-		Size:   foundation.Size{Width: 400, Height: 18},
-	})
-	contentView.AddSubview(buildLabel)
-
-	// Commit label - centered, tighter spacing
-	commitLabel := appkit.NewTextField()
-	commitLabel.SetStringValue(fmt.Sprintf("Commit  %s", commit))
-	commitLabel.SetEditable(false)
-	commitLabel.SetBordered(false)
-	commitLabel.SetDrawsBackground(false)
-	commitLabel.SetFont(appkit.Font_SystemFontOfSize(13))
-	commitLabel.SetAlignment(appkit.TextAlignmentCenter)
-	commitLabel.SetTextColor(appkit.Color_LinkColor())
-	commitLabel.SetFrame(foundation.Rect{
-		Origin: foundation.Point{X: 0, Y: 100},
-		Size:   foundation.Size{Width: 400, Height: 18},
-	})
-	contentView.AddSubview(commitLabel)
-
-	// GitHub button - centered
-	githubButton := appkit.Button_ButtonWithTitleTargetAction("GitHub", nil, objc.Selector{})
-	githubButton.SetBezelStyle(appkit.BezelStyleRounded)
-	githubButton.SetFrame(foundation.Rect{
-		Origin: foundation.Point{X: 150, Y: 40},
-		Size:   foundation.Size{Width: 100, Height: 32},
-	})
-
-	// Set button action to open GitHub URL
-	githubButton.SetTarget(githubButton.Object)
-	githubButton.SetAction(objc.Sel("performAction:"))
-
-	// Use action helper to handle click
-	action.Set(githubButton, func(sender objc.Object) {
-		url := foundation.URL_URLWithString("https://github.com/ljagiello/airdash")
-		appkit.Workspace_SharedWorkspace().OpenURL(url)
-	})
-
-	contentView.AddSubview(githubButton)
-
-	aboutWindow = window.Object
-	objc.Retain(&aboutWindow) // Retain to prevent deallocation
-	window.MakeKeyAndOrderFront(nil)
-	appkit.Application_SharedApplication().ActivateIgnoringOtherApps(true)
-}
-
 func main() {
-	// Handle subcommands first (install/uninstall/version)
+	// Handle subcommands first (install/uninstall/get/history/discover/run/exporter/bar/version)
 	if len(os.Args) > 1 {
 		switch os.Args[1] {
+		case "get":
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+			defer stop()
+			if err := runGet(ctx, os.Args[2:], os.Stdout); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			return
+		case "history":
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+			defer stop()
+			if err := runHistory(ctx, os.Args[2:], os.Stdout); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			return
+		case "discover":
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+			defer stop()
+			if err := runDiscover(ctx, os.Args[2:], os.Stdout, nil, nil); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			return
+		case "run":
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+			defer stop()
+			if err := runHeadless(ctx, os.Args[2:]); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			return
+		case "exporter":
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+			defer stop()
+			if err := runExporter(ctx, os.Args[2:]); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			return
+		case "bar":
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+			defer stop()
+			if err := runBar(ctx, os.Args[2:], os.Stdout); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			return
 		case "install":
 			if err := installDaemon(); err != nil {
 				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
@@ -175,7 +99,7 @@ func main() {
 	flag.Parse()
 
 	// Load config
-	cfg, err := LoadConfig(*configPath)
+	cfg, err := loadConfig(*configPath)
 	if err != nil {
 		logger.Error("Loading config", "error", err, "path", *configPath)
 		os.Exit(1)
@@ -185,87 +109,123 @@ func main() {
 	if cfg.Interval == 0 {
 		cfg.Interval = 60
 	}
+	if cfg.Aggregate == "" {
+		cfg.Aggregate = config.AggregateWorst
+	}
 
-	// Run GUI
-	runGUI(cfg)
-}
-
-func runGUI(cfg *Config) {
-	// Create the app manually instead of using RunApp
-	app := appkit.Application_SharedApplication()
-	app.SetActivationPolicy(appkit.ApplicationActivationPolicyAccessory)
-
-	// Schedule UI setup to run on main queue after app.Run() starts
-	dispatch.MainQueue().DispatchAsync(func() {
-		// Auto-install LaunchAgent silently on first launch
-		if !isDaemonInstalled() {
-			logger.Info("First launch detected - installing LaunchAgent")
-			if err := installDaemon(); err != nil {
-				// Log error but continue running in GUI mode
-				logger.Error("Failed to install LaunchAgent - running in GUI mode only", "error", err)
-			} else {
-				logger.Info("LaunchAgent installed successfully - exiting to let launchd start")
-				// Success - quit and let launchd start
-				app.Terminate(nil)
-				return
-			}
-		}
-
-		item := appkit.StatusBar_SystemStatusBar().StatusItemWithLength(-1)
-		objc.Retain(&item)
+	standard, err := aqi.Lookup(cfg.AQIStandard)
+	if err != nil {
+		logger.Error("Configuring AQI", "error", err)
+		os.Exit(1)
+	}
 
-		updateStatus := func() {
-			measures, err := getAirGradientMeasures(cfg.LocationID, cfg.Token)
-			if err != nil {
-				logger.Error("Fetching measures", "error", err)
-				return
+	ctx := context.Background()
+	s, cleanup, err := setup(ctx, cfg, cfg.Metrics.Listen)
+	if err != nil {
+		logger.Error("Configuring", "error", err)
+		os.Exit(1)
+	}
+	defer cleanup()
+	if s.served != nil {
+		go func() {
+			if err := <-s.served; err != nil {
+				logger.Error("Serving metrics", "error", err)
 			}
-			logger.Debug("AirGradientMeasures", "measures", measures)
+		}()
+	}
 
-			// convert the temperature to the desired unit
-			temperature := convertTemperature(measures.Atmp, cfg.TempUnit)
+	// Run GUI
+	runGUI(ctx, cfg, s.source, standard, s.alerts, s.notifier, cleanup)
+}
 
-			// updates to the ui should happen on the main thread to avoid segfaults
-			dispatch.MainQueue().DispatchAsync(func() {
-				item.Button().SetTitle(fmt.Sprintf("🌡️ %.2f  💨 %.0f  💧 %.1f  🫧 %.0f",
-					temperature,
-					measures.Pm02,
-					measures.Rhum,
-					measures.Rco2,
-				))
-			})
+// newNotifier returns a notifier delivering alert events to every configured
+// webhook.
+func newNotifier(cfg *config.Config) (notify.Notifier, error) {
+	notifiers := make(notify.Multi, 0, len(cfg.Webhooks))
+	for _, webhook := range cfg.Webhooks {
+		n, err := notify.NewWebhook(notify.WebhookConfig(webhook))
+		if err != nil {
+			return nil, err
 		}
+		notifiers = append(notifiers, n)
+	}
+	return notifiers, nil
+}
 
-		go func() {
-			// Fetch data immediately on startup
-			updateStatus()
-
-			ticker := time.NewTicker(time.Duration(cfg.Interval) * time.Second)
-			defer ticker.Stop()
+// loadConfig loads the config at path and checks the settings that are
+// parsed by the packages using them, so mistakes are reported at startup.
+func loadConfig(path string) (*config.Config, error) {
+	cfg, err := config.LoadConfig(path)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := render.ParseTitleTemplate(cfg.TitleTemplate); err != nil {
+		return nil, fmt.Errorf("invalid titleTemplate: %w", err)
+	}
+	if _, err := newLevels(cfg); err != nil {
+		return nil, fmt.Errorf("invalid levels: %w", err)
+	}
+	return cfg, nil
+}
 
-			// Continue fetching at regular intervals
-			for range ticker.C {
-				updateStatus()
-			}
-		}()
+// newLevels returns the classifier of the configured levels.
+func newLevels(cfg *config.Config) (*classify.Classifier, error) {
+	bands := make(map[string]classify.Bands, len(cfg.Levels))
+	for metric, b := range cfg.Levels {
+		bands[metric] = classify.Bands(b)
+	}
+	return classify.New(bands, cfg.TempUnit)
+}
 
-		// Create About menu item with callback
-		itemAbout := appkit.NewMenuItemWithAction("About AirDash", "", func(sender objc.Object) {
-			showAboutWindow()
-		})
+// newAlerts returns the alert engine of the configured rules.
+func newAlerts(cfg *config.Config) (*alert.Engine, error) {
+	rules := make([]alert.Rule, len(cfg.Alerts))
+	for i, rule := range cfg.Alerts {
+		rules[i] = alert.Rule(rule)
+	}
+	opts := []alert.Option{
+		alert.WithMetrics(render.ConvertMetrics(airgradient.Metrics, cfg.TempUnit)),
+		alert.WithMaxAge(cfg.StaleAfter(), cfg.Locations),
+	}
+	if cfg.Source == config.SourceLocal && cfg.Interval > 0 {
+		// Local readings are stamped when they are fetched, so the monitor
+		// is offline once fetching has failed for as long as readings may age
+		failures := int(math.Ceil(cfg.StaleAfter().Seconds() / float64(cfg.Interval)))
+		opts = append(opts, alert.WithMaxFailures(failures))
+	}
+	return alert.NewEngine(rules, opts...)
+}
 
-		// Create Quit menu item
-		itemQuit := appkit.NewMenuItem()
-		itemQuit.SetTitle("Quit")
-		itemQuit.SetAction(objc.Sel("terminate:"))
+// newSource returns the measures source selected by cfg, with the configured
+// PM2.5 correction applied.
+func newSource(cfg *config.Config) (airgradient.Source, error) {
+	source, err := newRawSource(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.PM25Correction == airgradient.CorrectionEPA {
+		return airgradient.CorrectedSource{Source: source}, nil
+	}
+	return source, nil
+}
 
-		// Build menu
-		menu := appkit.NewMenu()
-		menu.AddItem(itemAbout)
-		menu.AddItem(appkit.MenuItem_SeparatorItem())
-		menu.AddItem(itemQuit)
-		item.SetMenu(menu)
-	})
+// newRawSource returns the measures source selected by cfg.Source, which
+// config.LoadConfig has already validated.
+func newRawSource(cfg *config.Config) (airgradient.Source, error) {
+	if cfg.Source != config.SourceLocal {
+		return airgradient.CloudSource{Client: newClient(cfg), LocationID: cfg.LocationID}, nil
+	}
+	if cfg.Device.Host == "" {
+		return nil, errors.New("source \"local\" needs device.host in the config")
+	}
+	return airgradient.NewLocalSource(cfg.Device.Host, cfg.Device.Name, airgradient.WithUserAgent("airdash/"+version)), nil
+}
 
-	app.Run()
+// newClient returns a cloud API client for cfg. The options are applied after
+// the configured ones.
+func newClient(cfg *config.Config, opts ...airgradient.Option) *airgradient.Client {
+	return airgradient.NewClient(append([]airgradient.Option{
+		airgradient.WithToken(cfg.Token),
+		airgradient.WithUserAgent("airdash/" + version),
+	}, opts...)...)
 }
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ljagiello/airdash/airgradient"
+	"github.com/ljagiello/airdash/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewSource(t *testing.T) {
+	testCases := []struct {
+		name     string
+		cfg      config.Config
+		expected airgradient.Source
+		err      string
+	}{
+		{
+			"default-cloud",
+			config.Config{Token: "1234567890", LocationID: 12345},
+			airgradient.CloudSource{},
+			"",
+		},
+		{
+			"local",
+			config.Config{Source: config.SourceLocal, Device: config.Device{Host: "airgradient_84fce612f5b8.local"}},
+			&airgradient.LocalSource{},
+			"",
+		},
+		{
+			"local-without-host",
+			config.Config{Source: config.SourceLocal},
+			nil,
+			`source "local" needs device.host in the config`,
+		},
+		{
+			"epa-correction",
+			config.Config{Token: "1234567890", PM25Correction: airgradient.CorrectionEPA},
+			airgradient.CorrectedSource{},
+			"",
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.name, func(t *testing.T) {
+			source, err := newSource(&tC.cfg)
+			if tC.err != "" {
+				assert.EqualError(t, err, tC.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.IsType(t, tC.expected, source)
+		})
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	testCases := []struct {
+		name   string
+		config string
+		err    string
+	}{
+		{"valid", "titleTemplate: '{{round 0 .Rco2}} ppm'\nlevels:\n  rco2:\n    high: [800, 1200, 2000]\n", ""},
+		{"template-syntax", "titleTemplate: '{{round 0 .Rco2'", "invalid titleTemplate: template: title:1: unclosed action"},
+		{"template-unknown-field", "titleTemplate: '{{round 0 .CO2}}'", "can't evaluate field CO2"},
+		{"levels-unknown-metric", "levels:\n  co2:\n    high: [800]\n", `invalid levels: invalid level bands: unknown metric "co2"`},
+		{"levels-out-of-order", "levels:\n  rco2:\n    high: [1200, 800]\n", "invalid levels: invalid level bands: rco2: high edges [1200 800] must increase"},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.name, func(t *testing.T) {
+			configPath := filepath.Join(t.TempDir(), "config.yaml")
+			require.NoError(t, os.WriteFile(configPath, []byte(tC.config), 0o600))
+			_, err := loadConfig(configPath)
+			if tC.err != "" {
+				require.ErrorContains(t, err, tC.err)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestOpenStoreExpandsHome(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	s, err := openStore(&config.Config{History: config.History{Path: "~/data/history.db"}})
+	require.NoError(t, err)
+	require.NoError(t, s.Close())
+	assert.FileExists(t, filepath.Join(home, "data", "history.db"))
+}
+
+func TestNewAlertsLocalFailures(t *testing.T) {
+	testCases := []struct {
+		name     string
+		source   string
+		failures int
+	}{
+		// Five intervals, the default maximum age
+		{"local", config.SourceLocal, 5},
+		{"cloud", config.SourceCloud, 0},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.name, func(t *testing.T) {
+			alerts, err := newAlerts(&config.Config{Source: tC.source, Interval: 120})
+			require.NoError(t, err)
+			alerts.Evaluate([]airgradient.Measures{{LocationName: "Living Room", Timestamp: time.Now()}})
+
+			failures := 0
+			for range 20 {
+				failures++
+				if len(alerts.Failed()) > 0 {
+					break
+				}
+			}
+			if tC.failures == 0 {
+				assert.Empty(t, alerts.Active())
+				return
+			}
+			assert.Equal(t, tC.failures, failures)
+		})
+	}
+}
@@ -0,0 +1,103 @@
+package mqtt
+
+import (
+	"encoding/json"
+
+	"github.com/ljagiello/airdash/airgradient"
+	"github.com/ljagiello/airdash/render"
+)
+
+// deviceClasses maps metrics to Home Assistant sensor device classes.
+// Metrics without one, such as the VOC and NOx indices, are plain sensors.
+var deviceClasses = map[string]string{
+	"pm01":             "pm1",
+	"pm02":             "pm25",
+	"pm10":             "pm10",
+	"atmp":             "temperature",
+	"rhum":             "humidity",
+	"rco2":             "carbon_dioxide",
+	"tvoc":             "volatile_organic_compounds_parts",
+	"wifi":             "signal_strength",
+	"dewPoint":         "temperature",
+	"heatIndex":        "temperature",
+	"wetBulb":          "temperature",
+	"absoluteHumidity": "absolute_humidity",
+}
+
+// discovery is a Home Assistant discovery config message.
+type discovery struct {
+	topic   string
+	payload []byte
+}
+
+// discoveryConfig is the payload of a sensor's discovery config.
+type discoveryConfig struct {
+	Name              string          `json:"name"`
+	UniqueID          string          `json:"unique_id"`
+	ObjectID          string          `json:"object_id"`
+	StateTopic        string          `json:"state_topic"`
+	ValueTemplate     string          `json:"value_template"`
+	UnitOfMeasurement string          `json:"unit_of_measurement,omitempty"`
+	DeviceClass       string          `json:"device_class,omitempty"`
+	StateClass        string          `json:"state_class"`
+	EntityCategory    string          `json:"entity_category,omitempty"`
+	AvailabilityTopic string          `json:"availability_topic"`
+	Device            discoveryDevice `json:"device"`
+}
+
+type discoveryDevice struct {
+	Identifiers  []string `json:"identifiers"`
+	Name         string   `json:"name"`
+	Manufacturer string   `json:"manufacturer"`
+	Model        string   `json:"model,omitempty"`
+	SerialNumber string   `json:"serial_number,omitempty"`
+	SWVersion    string   `json:"sw_version,omitempty"`
+}
+
+// discoveries returns the discovery configs of the location's present
+// readings that have not been announced since connecting.
+func (p *Publisher) discoveries(m airgradient.Measures) []discovery {
+	node := locationNode(m)
+	device := discoveryDevice{
+		Identifiers:  []string{"airdash_" + node},
+		Name:         render.LocationLabel(m),
+		Manufacturer: "AirGradient",
+		Model:        m.Model,
+		SerialNumber: m.Serialno,
+		SWVersion:    m.FirmwareVersion,
+	}
+
+	var discoveries []discovery
+	for _, metric := range airgradient.Metrics {
+		if !metric.Value(m).Valid {
+			continue
+		}
+		topic := p.config.HomeAssistant.Prefix + "/sensor/airdash_" + node + "/" + metric.Name + "/config"
+		p.mu.Lock()
+		announced := p.announced[topic]
+		p.mu.Unlock()
+		if announced {
+			continue
+		}
+
+		config := discoveryConfig{
+			Name:              metric.Label,
+			UniqueID:          "airdash_" + node + "_" + metric.Name,
+			ObjectID:          "airdash_" + node + "_" + metric.Name,
+			StateTopic:        p.stateTopic(m),
+			ValueTemplate:     "{{ value_json." + metric.Name + " }}",
+			UnitOfMeasurement: metric.Unit,
+			DeviceClass:       deviceClasses[metric.Name],
+			StateClass:        "measurement",
+			AvailabilityTopic: p.availabilityTopic(),
+			Device:            device,
+		}
+		if metric.Name == "wifi" {
+			config.EntityCategory = "diagnostic"
+		}
+		// Marshalling a struct of strings cannot fail
+		payload, _ := json.Marshal(config)
+		discoveries = append(discoveries, discovery{topic: topic, payload: payload})
+	}
+	return discoveries
+}
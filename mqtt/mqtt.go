@@ -0,0 +1,313 @@
+// Package mqtt publishes measures to an MQTT broker, with Home Assistant
+// MQTT discovery so every reading shows up as a sensor.
+package mqtt
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	paho "github.com/eclipse/paho.mqtt.golang"
+	"github.com/ljagiello/airdash/airgradient"
+)
+
+const (
+	// DefaultTopicPrefix is the prefix of the state and availability topics.
+	DefaultTopicPrefix = "airdash"
+	// DefaultDiscoveryPrefix is Home Assistant's default discovery prefix.
+	DefaultDiscoveryPrefix = "homeassistant"
+	// DefaultTimeout bounds connecting and each publish.
+	DefaultTimeout = 10 * time.Second
+)
+
+var schemes = []string{"tcp", "mqtt", "ssl", "tls", "mqtts", "ws", "wss"}
+
+// ErrInvalidConfig is returned by NewPublisher for a broker it cannot use.
+var ErrInvalidConfig = errors.New("invalid MQTT config")
+
+// Config is an MQTT broker as configured in config.yaml:
+//
+//	mqtt:
+//	  broker: tcp://homeassistant.local:1883
+//	  username: airdash
+//	  password: s3cret
+//	  topicPrefix: airdash
+//	  qos: 1
+//	  retain: true
+type Config struct {
+	// Broker is the broker URL, e.g. "tcp://host:1883", "ssl://host:8883"
+	// or "ws://host:9001/mqtt".
+	Broker string `yaml:"broker"`
+	// ClientID identifies the connection, "airdash-<hostname>" by default.
+	ClientID string `yaml:"clientId"`
+	Username string `yaml:"username"`
+	Password string `yaml:"password"`
+	// TopicPrefix prefixes the state and availability topics,
+	// DefaultTopicPrefix by default.
+	TopicPrefix string `yaml:"topicPrefix"`
+	// QoS is the quality of service of every message, 0, 1 or 2.
+	QoS byte `yaml:"qos"`
+	// Retain keeps the latest state on the broker for new subscribers.
+	// Discovery and availability messages are always retained.
+	Retain bool `yaml:"retain"`
+	// Timeout bounds connecting and each publish, DefaultTimeout by default.
+	Timeout time.Duration `yaml:"timeout"`
+	TLS     TLS           `yaml:"tls"`
+	// HomeAssistant configures MQTT discovery.
+	HomeAssistant HomeAssistant `yaml:"homeAssistant"`
+}
+
+// TLS configures the connection to an ssl://, mqtts:// or wss:// broker.
+type TLS struct {
+	// CAFile verifies the broker with this CA instead of the system roots.
+	CAFile string `yaml:"caFile"`
+	// CertFile and KeyFile authenticate the client with a certificate.
+	CertFile string `yaml:"certFile"`
+	KeyFile  string `yaml:"keyFile"`
+	// InsecureSkipVerify disables verifying the broker's certificate.
+	InsecureSkipVerify bool `yaml:"insecureSkipVerify"`
+}
+
+// HomeAssistant configures Home Assistant MQTT discovery.
+type HomeAssistant struct {
+	// Discovery publishes a discovery config per reading.
+	Discovery bool `yaml:"discovery"`
+	// Prefix is the discovery prefix, DefaultDiscoveryPrefix by default.
+	Prefix string `yaml:"prefix"`
+}
+
+// Publisher publishes the measures of every location as a JSON state
+// message to "<prefix>/<node>/state", where node is the location ID, or the
+// serial number of a local monitor. It is safe for concurrent use.
+type Publisher struct {
+	config Config
+	client paho.Client
+
+	mu sync.Mutex
+	// announced holds the discovery topics published since connecting.
+	announced map[string]bool
+}
+
+// NewPublisher returns a publisher for the broker, or an error wrapping
+// ErrInvalidConfig. It connects on the first Publish.
+func NewPublisher(config Config) (*Publisher, error) {
+	if config.TopicPrefix == "" {
+		config.TopicPrefix = DefaultTopicPrefix
+	}
+	config.TopicPrefix = strings.TrimSuffix(config.TopicPrefix, "/")
+	if config.HomeAssistant.Prefix == "" {
+		config.HomeAssistant.Prefix = DefaultDiscoveryPrefix
+	}
+	if config.Timeout == 0 {
+		config.Timeout = DefaultTimeout
+	}
+	if config.ClientID == "" {
+		hostname, _ := os.Hostname()
+		config.ClientID = "airdash-" + nodeID(hostname)
+	}
+
+	u, err := url.Parse(config.Broker)
+	if err != nil || !slices.Contains(schemes, u.Scheme) || u.Host == "" {
+		return nil, fmt.Errorf("%w: broker %q must be a URL with one of the schemes %v", ErrInvalidConfig, config.Broker, schemes)
+	}
+	if config.QoS > 2 {
+		return nil, fmt.Errorf("%w: qos must be 0, 1 or 2, got %d", ErrInvalidConfig, config.QoS)
+	}
+	tlsConfig, err := config.TLS.load()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidConfig, err)
+	}
+
+	p := &Publisher{config: config, announced: make(map[string]bool)}
+	opts := paho.NewClientOptions().
+		AddBroker(config.Broker).
+		SetClientID(config.ClientID).
+		SetUsername(config.Username).
+		SetPassword(config.Password).
+		SetConnectTimeout(config.Timeout).
+		SetAutoReconnect(true).
+		SetWill(p.availabilityTopic(), "offline", config.QoS, true).
+		SetOnConnectHandler(p.onConnect)
+	if tlsConfig != nil {
+		opts.SetTLSConfig(tlsConfig)
+	}
+	p.client = paho.NewClient(opts)
+	return p, nil
+}
+
+// load returns the TLS client config, or nil when nothing is configured.
+func (t TLS) load() (*tls.Config, error) {
+	if t == (TLS{}) {
+		return nil, nil
+	}
+	config := &tls.Config{InsecureSkipVerify: t.InsecureSkipVerify} //nolint:gosec // G402: opt-in for self-signed brokers
+	if t.CAFile != "" {
+		pem, err := os.ReadFile(t.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("reading CA file: %w", err)
+		}
+		config.RootCAs = x509.NewCertPool()
+		if !config.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates in CA file %s", t.CAFile)
+		}
+	}
+	if t.CertFile != "" || t.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(t.CertFile, t.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("loading client certificate: %w", err)
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+	return config, nil
+}
+
+// onConnect marks AirDash available and, after a reconnect, announces every
+// sensor again in case the broker lost its retained messages.
+func (p *Publisher) onConnect(client paho.Client) {
+	p.mu.Lock()
+	clear(p.announced)
+	p.mu.Unlock()
+
+	client.Publish(p.availabilityTopic(), p.config.QoS, true, "online")
+	if p.config.HomeAssistant.Discovery {
+		// Home Assistant announces a restart with "online"
+		client.Subscribe(p.config.HomeAssistant.Prefix+"/status", p.config.QoS, func(_ paho.Client, msg paho.Message) {
+			if string(msg.Payload()) == "online" {
+				p.mu.Lock()
+				clear(p.announced)
+				p.mu.Unlock()
+			}
+		})
+	}
+}
+
+// Publish sends the discovery configs of readings not announced yet and the
+// state of every location.
+func (p *Publisher) Publish(ctx context.Context, measures []airgradient.Measures) error {
+	if err := p.connect(ctx); err != nil {
+		return err
+	}
+
+	for _, m := range measures {
+		if p.config.HomeAssistant.Discovery {
+			for _, d := range p.discoveries(m) {
+				if err := p.publish(ctx, d.topic, true, d.payload); err != nil {
+					return err
+				}
+				p.mu.Lock()
+				p.announced[d.topic] = true
+				p.mu.Unlock()
+			}
+		}
+
+		state, err := json.Marshal(newState(m))
+		if err != nil {
+			return fmt.Errorf("encoding MQTT state: %w", err)
+		}
+		if err := p.publish(ctx, p.stateTopic(m), p.config.Retain, state); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close marks AirDash unavailable and disconnects.
+func (p *Publisher) Close() error {
+	if p.client.IsConnectionOpen() {
+		token := p.client.Publish(p.availabilityTopic(), p.config.QoS, true, "offline")
+		token.WaitTimeout(p.config.Timeout)
+	}
+	p.client.Disconnect(250)
+	return nil
+}
+
+// connect connects to the broker unless a connection is open.
+func (p *Publisher) connect(ctx context.Context) error {
+	if p.client.IsConnectionOpen() {
+		return nil
+	}
+	if p.client.IsConnected() {
+		return fmt.Errorf("reconnecting to MQTT broker %s", p.config.Broker)
+	}
+	if err := p.wait(ctx, p.client.Connect()); err != nil {
+		return fmt.Errorf("connecting to MQTT broker %s: %w", p.config.Broker, err)
+	}
+	return nil
+}
+
+// publish sends a single message and waits for it to be delivered at the
+// configured QoS.
+func (p *Publisher) publish(ctx context.Context, topic string, retain bool, payload []byte) error {
+	if err := p.wait(ctx, p.client.Publish(topic, p.config.QoS, retain, payload)); err != nil {
+		return fmt.Errorf("publishing to MQTT topic %s: %w", topic, err)
+	}
+	return nil
+}
+
+// wait waits for the token until the timeout or until ctx is done.
+func (p *Publisher) wait(ctx context.Context, token paho.Token) error {
+	timer := time.NewTimer(p.config.Timeout)
+	defer timer.Stop()
+	select {
+	case <-token.Done():
+		return token.Error()
+	case <-timer.C:
+		return errors.New("timed out")
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (p *Publisher) availabilityTopic() string {
+	return p.config.TopicPrefix + "/status"
+}
+
+func (p *Publisher) stateTopic(m airgradient.Measures) string {
+	return p.config.TopicPrefix + "/" + locationNode(m) + "/state"
+}
+
+// newState returns the state message of a location: every present reading
+// and derived metric by API field name, temperatures in °C, plus the
+// location and the time of the reading.
+func newState(m airgradient.Measures) map[string]any {
+	state := map[string]any{
+		"locationId":   m.LocationID,
+		"locationName": m.LocationName,
+		"serialno":     m.Serialno,
+		"timestamp":    m.Timestamp.UTC().Format(time.RFC3339),
+	}
+	for _, metric := range airgradient.Metrics {
+		if v := metric.Value(m); v.Valid {
+			state[metric.Name] = v.Float64
+		}
+	}
+	return state
+}
+
+// locationNode returns the node ID of a location in topics and unique IDs.
+func locationNode(m airgradient.Measures) string {
+	if m.LocationID != 0 {
+		return strconv.Itoa(m.LocationID)
+	}
+	return nodeID(m.Serialno)
+}
+
+// nodeID keeps only the characters Home Assistant allows in a node ID.
+func nodeID(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_' || r == '-' {
+			return r
+		}
+		return '_'
+	}, s)
+}
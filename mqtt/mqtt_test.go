@@ -0,0 +1,223 @@
+package mqtt
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/ljagiello/airdash/airgradient"
+	mochi "github.com/mochi-mqtt/server/v2"
+	"github.com/mochi-mqtt/server/v2/hooks/auth"
+	"github.com/mochi-mqtt/server/v2/listeners"
+	"github.com/mochi-mqtt/server/v2/packets"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testMeasures = []airgradient.Measures{{
+	LocationID:      12345,
+	LocationName:    "Test Loc",
+	Serialno:        "aabb12",
+	FirmwareVersion: "3.1.1",
+	Model:           "I-9PSL",
+	Pm02:            airgradient.NewValue(4),
+	Atmp:            airgradient.NewValue(24.3),
+	Rhum:            airgradient.NewValue(52),
+	Rco2:            airgradient.NewValue(548),
+	Timestamp:       time.Date(2023, 10, 10, 3, 42, 11, 0, time.UTC),
+}}
+
+// message is a message received by the test broker.
+type message struct {
+	topic   string
+	payload string
+	retain  bool
+}
+
+// newBroker starts an in-process broker and returns its URL and the
+// messages published to it.
+func newBroker(t *testing.T, hook mochi.Hook) (*mochi.Server, string, chan message) {
+	t.Helper()
+	server := mochi.New(&mochi.Options{
+		InlineClient: true,
+		Logger:       slog.New(slog.NewTextHandler(io.Discard, nil)),
+	})
+	if hook == nil {
+		hook = new(auth.AllowHook)
+	}
+	require.NoError(t, server.AddHook(hook, nil))
+	tcp := listeners.NewTCP(listeners.Config{ID: "test", Address: "127.0.0.1:0"})
+	require.NoError(t, server.AddListener(tcp))
+	go func() { _ = server.Serve() }()
+	t.Cleanup(func() { _ = server.Close() })
+
+	received := make(chan message, 100)
+	require.NoError(t, server.Subscribe("#", 1, func(_ *mochi.Client, _ packets.Subscription, pk packets.Packet) {
+		received <- message{topic: pk.TopicName, payload: string(pk.Payload), retain: pk.FixedHeader.Retain}
+	}))
+	return server, "tcp://" + tcp.Address(), received
+}
+
+// collect returns the messages received until none arrives for a while,
+// keyed by topic.
+func collect(received chan message) map[string]message {
+	messages := make(map[string]message)
+	for {
+		select {
+		case m := <-received:
+			messages[m.topic] = m
+		case <-time.After(300 * time.Millisecond):
+			return messages
+		}
+	}
+}
+
+func TestPublish(t *testing.T) {
+	_, broker, received := newBroker(t, nil)
+	p, err := NewPublisher(Config{Broker: broker, QoS: 1, Retain: true, HomeAssistant: HomeAssistant{Discovery: true}})
+	require.NoError(t, err)
+	defer func() { _ = p.Close() }()
+
+	require.NoError(t, p.Publish(context.Background(), testMeasures))
+	messages := collect(received)
+
+	assert.Equal(t, "online", messages["airdash/status"].payload)
+
+	state := messages["airdash/12345/state"]
+	assert.True(t, state.retain)
+	assert.JSONEq(t, `{
+		"locationId": 12345,
+		"locationName": "Test Loc",
+		"serialno": "aabb12",
+		"timestamp": "2023-10-10T03:42:11Z",
+		"pm02": 4,
+		"atmp": 24.3,
+		"rhum": 52,
+		"rco2": 548,
+		"dewPoint": 13.82,
+		"heatIndex": 24.14,
+		"humidex": 27.56,
+		"absoluteHumidity": 11.51,
+		"wetBulb": 17.7
+	}`, roundJSON(t, state.payload))
+
+	discovery := messages["homeassistant/sensor/airdash_12345/rco2/config"]
+	assert.True(t, discovery.retain)
+	assert.JSONEq(t, `{
+		"name": "CO2",
+		"unique_id": "airdash_12345_rco2",
+		"object_id": "airdash_12345_rco2",
+		"state_topic": "airdash/12345/state",
+		"value_template": "{{ value_json.rco2 }}",
+		"unit_of_measurement": "ppm",
+		"device_class": "carbon_dioxide",
+		"state_class": "measurement",
+		"availability_topic": "airdash/status",
+		"device": {
+			"identifiers": ["airdash_12345"],
+			"name": "Test Loc",
+			"manufacturer": "AirGradient",
+			"model": "I-9PSL",
+			"serial_number": "aabb12",
+			"sw_version": "3.1.1"
+		}
+	}`, discovery.payload)
+	assert.Contains(t, messages, "homeassistant/sensor/airdash_12345/atmp/config")
+	// Missing readings get no sensor
+	assert.NotContains(t, messages, "homeassistant/sensor/airdash_12345/pm01/config")
+
+	// Discovery is only sent once per connection
+	require.NoError(t, p.Publish(context.Background(), testMeasures))
+	messages = collect(received)
+	assert.Contains(t, messages, "airdash/12345/state")
+	assert.NotContains(t, messages, "homeassistant/sensor/airdash_12345/rco2/config")
+}
+
+func TestPublishRediscoversAfterHomeAssistantRestart(t *testing.T) {
+	server, broker, received := newBroker(t, nil)
+	p, err := NewPublisher(Config{Broker: broker, TopicPrefix: "air/", HomeAssistant: HomeAssistant{Discovery: true, Prefix: "ha"}})
+	require.NoError(t, err)
+	defer func() { _ = p.Close() }()
+
+	require.NoError(t, p.Publish(context.Background(), testMeasures))
+	messages := collect(received)
+	assert.Contains(t, messages, "ha/sensor/airdash_12345/rco2/config")
+	assert.False(t, messages["air/12345/state"].retain)
+
+	require.NoError(t, server.Publish("ha/status", []byte("online"), false, 0))
+	collect(received)
+
+	require.NoError(t, p.Publish(context.Background(), testMeasures))
+	messages = collect(received)
+	assert.Contains(t, messages, "ha/sensor/airdash_12345/rco2/config")
+}
+
+func TestPublishWithoutDiscovery(t *testing.T) {
+	_, broker, received := newBroker(t, nil)
+	local := testMeasures[0]
+	local.LocationID = 0
+	p, err := NewPublisher(Config{Broker: broker})
+	require.NoError(t, err)
+	defer func() { _ = p.Close() }()
+
+	require.NoError(t, p.Publish(context.Background(), []airgradient.Measures{local}))
+	messages := collect(received)
+	assert.Contains(t, messages, "airdash/aabb12/state")
+	for topic := range messages {
+		assert.NotContains(t, topic, "homeassistant")
+	}
+}
+
+func TestPublishCredentials(t *testing.T) {
+	hook := new(auth.Hook)
+	_, broker, _ := newBroker(t, hook)
+	require.NoError(t, hook.Init(&auth.Options{Ledger: &auth.Ledger{
+		Auth: auth.AuthRules{{Username: "airdash", Password: "s3cret", Allow: true}},
+		ACL:  auth.ACLRules{{Username: "airdash", Filters: auth.Filters{"#": auth.ReadWrite}}},
+	}}))
+
+	p, err := NewPublisher(Config{Broker: broker, Username: "airdash", Password: "wrong", Timeout: time.Second})
+	require.NoError(t, err)
+	require.Error(t, p.Publish(context.Background(), testMeasures))
+
+	p, err = NewPublisher(Config{Broker: broker, Username: "airdash", Password: "s3cret"})
+	require.NoError(t, err)
+	defer func() { _ = p.Close() }()
+	require.NoError(t, p.Publish(context.Background(), testMeasures))
+}
+
+func TestNewPublisherInvalid(t *testing.T) {
+	testCases := []struct {
+		name   string
+		config Config
+	}{
+		{"no-broker", Config{}},
+		{"bad-scheme", Config{Broker: "http://broker:1883"}},
+		{"bad-qos", Config{Broker: "tcp://broker:1883", QoS: 3}},
+		{"missing-ca", Config{Broker: "ssl://broker:8883", TLS: TLS{CAFile: "testdata/missing.pem"}}},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.name, func(t *testing.T) {
+			_, err := NewPublisher(tC.config)
+			require.ErrorIs(t, err, ErrInvalidConfig)
+		})
+	}
+}
+
+// roundJSON rounds the numbers of a JSON object to two decimals.
+func roundJSON(t *testing.T, payload string) string {
+	t.Helper()
+	var object map[string]any
+	require.NoError(t, json.Unmarshal([]byte(payload), &object))
+	for key, value := range object {
+		if f, ok := value.(float64); ok {
+			object[key] = float64(int(f*100+0.5)) / 100
+		}
+	}
+	rounded, err := json.Marshal(object)
+	require.NoError(t, err)
+	return string(rounded)
+}
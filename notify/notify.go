@@ -0,0 +1,29 @@
+// Package notify delivers alert events to external services.
+package notify
+
+import (
+	"context"
+	"errors"
+
+	"github.com/ljagiello/airdash/alert"
+)
+
+// Notifier delivers alert events.
+type Notifier interface {
+	Notify(ctx context.Context, event alert.Event) error
+}
+
+// Multi delivers every event to each of its notifiers in turn.
+type Multi []Notifier
+
+// Notify delivers the event to every notifier, even if some fail, and
+// returns their joined errors.
+func (m Multi) Notify(ctx context.Context, event alert.Event) error {
+	var errs []error
+	for _, n := range m {
+		if err := n.Notify(ctx, event); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,319 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/ljagiello/airdash/alert"
+	"github.com/ljagiello/airdash/internal/sleep"
+)
+
+// Webhook presets shape the request for a particular service.
+const (
+	// PresetGeneric posts the event as JSON (the default).
+	PresetGeneric = "generic"
+	// PresetSlack posts a Slack incoming webhook message.
+	PresetSlack = "slack"
+	// PresetDiscord posts a Discord webhook message.
+	PresetDiscord = "discord"
+	// PresetTeams posts a Microsoft Teams connector card.
+	PresetTeams = "teams"
+	// PresetNtfy publishes a plain text message to an ntfy topic URL.
+	PresetNtfy = "ntfy"
+)
+
+var presets = []string{PresetGeneric, PresetSlack, PresetDiscord, PresetTeams, PresetNtfy}
+
+const (
+	// DefaultWebhookTimeout bounds each attempt to deliver a webhook.
+	DefaultWebhookTimeout = 10 * time.Second
+	// SignatureHeader carries the HMAC-SHA256 signature of the body as
+	// "sha256=<hex>" when a secret is configured.
+	SignatureHeader = "X-Airdash-Signature"
+	// UserAgent is sent with every webhook.
+	UserAgent = "airdash"
+)
+
+// Retry controls how failed deliveries are retried.
+type Retry struct {
+	// MaxAttempts is the total number of attempts, including the first one.
+	// Values below 2 disable retries.
+	MaxAttempts int
+	// Delay is the wait before the first retry. It doubles on every further
+	// attempt.
+	Delay time.Duration
+}
+
+// DefaultRetry tries a delivery three times, waiting a second and then two.
+var DefaultRetry = Retry{MaxAttempts: 3, Delay: time.Second}
+
+// backoff returns the delay before the retry following the given attempt.
+func (r Retry) backoff(attempt int) time.Duration {
+	delay := r.Delay
+	for i := 1; i < attempt && delay < math.MaxInt64/2; i++ {
+		delay *= 2
+	}
+	return delay
+}
+
+// ErrInvalidWebhook is returned by NewWebhook for a webhook it cannot send.
+var ErrInvalidWebhook = errors.New("invalid webhook")
+
+// WebhookConfig is a webhook as configured in config.yaml.
+type WebhookConfig struct {
+	// URL receives a POST per alert event.
+	URL string `yaml:"url"`
+	// Preset is one of "generic", "slack", "discord", "teams" and "ntfy".
+	Preset string `yaml:"preset"`
+	// Template, when set, is a text/template for the request body, executed
+	// with the alert.Event. It replaces the preset's body.
+	Template string `yaml:"template"`
+	// Secret, when set, signs the body with HMAC-SHA256 in SignatureHeader.
+	Secret string `yaml:"secret"`
+	// Timeout bounds each attempt, DefaultWebhookTimeout by default.
+	Timeout time.Duration `yaml:"timeout"`
+	// Headers are added to the request, e.g. an Authorization header.
+	Headers map[string]string `yaml:"headers"`
+}
+
+// Webhook is a Notifier that POSTs alert events to a URL.
+type Webhook struct {
+	config     WebhookConfig
+	endpoint   string
+	template   *template.Template
+	httpClient *http.Client
+	retry      Retry
+	sleep      func(ctx context.Context, d time.Duration) error
+}
+
+// Option configures a Webhook.
+type Option func(*Webhook)
+
+// WithHTTPClient sets the HTTP client used to send the webhook.
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(w *Webhook) {
+		w.httpClient = httpClient
+	}
+}
+
+// WithRetry sets how failed deliveries are retried, DefaultRetry by default.
+func WithRetry(retry Retry) Option {
+	return func(w *Webhook) {
+		w.retry = retry
+	}
+}
+
+// NewWebhook returns a notifier for the webhook, or an error wrapping
+// ErrInvalidWebhook if its URL, preset or template is invalid.
+func NewWebhook(config WebhookConfig, opts ...Option) (*Webhook, error) {
+	if config.Preset == "" {
+		config.Preset = PresetGeneric
+	}
+	if config.Timeout == 0 {
+		config.Timeout = DefaultWebhookTimeout
+	}
+
+	u, err := url.Parse(config.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		// Chat webhook URLs carry their secret, so the URL is not echoed
+		return nil, fmt.Errorf("%w: URL must be an http or https URL", ErrInvalidWebhook)
+	}
+	if !slices.Contains(presets, config.Preset) {
+		return nil, fmt.Errorf("%w %s: unknown preset %q, expected one of %v", ErrInvalidWebhook, u.Host, config.Preset, presets)
+	}
+
+	w := &Webhook{
+		config:     config,
+		endpoint:   u.Scheme + "://" + u.Host,
+		httpClient: http.DefaultClient,
+		retry:      DefaultRetry,
+		sleep:      sleep.Context,
+	}
+	if config.Template != "" {
+		w.template, err = template.New("webhook").Option("missingkey=error").Parse(config.Template)
+		if err != nil {
+			return nil, fmt.Errorf("%w %s: %w", ErrInvalidWebhook, u.Host, err)
+		}
+	}
+	for _, opt := range opts {
+		opt(w)
+	}
+	return w, nil
+}
+
+// Notify POSTs the event, retrying transport failures, rate limiting and
+// server errors with backoff.
+func (w *Webhook) Notify(ctx context.Context, event alert.Event) error {
+	body, contentType, headers, err := w.request(event)
+	if err != nil {
+		return err
+	}
+	if w.config.Secret != "" {
+		headers[SignatureHeader] = Sign(w.config.Secret, body)
+	}
+
+	for attempt := 1; ; attempt++ {
+		err = w.send(ctx, body, contentType, headers)
+		if err == nil || attempt >= w.retry.MaxAttempts || ctx.Err() != nil || !isRetryable(err) {
+			return err
+		}
+		if sleepErr := w.sleep(ctx, w.retry.backoff(attempt)); sleepErr != nil {
+			return fmt.Errorf("waiting to retry: %w", sleepErr)
+		}
+	}
+}
+
+// send makes a single attempt to deliver the body.
+func (w *Webhook) send(ctx context.Context, body []byte, contentType string, headers map[string]string) error {
+	ctx, cancel := context.WithTimeout(ctx, w.config.Timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.config.URL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("creating webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("User-Agent", UserAgent)
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+	for key, value := range w.config.Headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := w.httpClient.Do(req)
+	if err != nil {
+		// Slack, Discord and Teams keep the secret in the path
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			urlErr.URL = w.endpoint
+		}
+		return fmt.Errorf("sending webhook: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		snippet, _ := io.ReadAll(io.LimitReader(resp.Body, 200))
+		return &StatusError{StatusCode: resp.StatusCode, URL: w.endpoint, Body: strings.TrimSpace(string(snippet))}
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	return nil
+}
+
+// StatusError is a webhook response outside the 2xx range. URL holds only
+// the scheme and host, as the path of chat webhooks carries their secret.
+type StatusError struct {
+	StatusCode int
+	URL        string
+	Body       string
+}
+
+func (e *StatusError) Error() string {
+	msg := fmt.Sprintf("HTTP %d from webhook %s", e.StatusCode, e.URL)
+	if e.Body != "" {
+		msg += ": " + e.Body
+	}
+	return msg
+}
+
+// isRetryable reports whether a failed delivery is worth retrying.
+func isRetryable(err error) bool {
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.StatusCode == http.StatusTooManyRequests || statusErr.StatusCode >= 500
+	}
+	var urlErr *url.Error
+	return errors.As(err, &urlErr)
+}
+
+// request renders the body, content type and preset headers of the event.
+func (w *Webhook) request(event alert.Event) ([]byte, string, map[string]string, error) {
+	headers := make(map[string]string)
+	if w.config.Preset == PresetNtfy {
+		headers["Title"] = fmt.Sprintf("%s %s", event.Rule, event.State)
+		headers["Tags"] = "rotating_light"
+		headers["Priority"] = "high"
+		if event.State == alert.Resolved {
+			headers["Tags"] = "white_check_mark"
+			headers["Priority"] = "default"
+		}
+	}
+
+	if w.template != nil {
+		var buf bytes.Buffer
+		if err := w.template.Execute(&buf, event); err != nil {
+			return nil, "", nil, fmt.Errorf("rendering webhook template: %w", err)
+		}
+		contentType := "application/json"
+		if w.config.Preset == PresetNtfy || !json.Valid(buf.Bytes()) {
+			contentType = "text/plain; charset=utf-8"
+		}
+		return buf.Bytes(), contentType, headers, nil
+	}
+
+	var payload any
+	switch w.config.Preset {
+	case PresetSlack:
+		payload = map[string]string{"text": Message(event)}
+	case PresetDiscord:
+		payload = map[string]string{"content": Message(event)}
+	case PresetTeams:
+		color := "D13438"
+		if event.State == alert.Resolved {
+			color = "2EB886"
+		}
+		payload = map[string]string{
+			"@type":      "MessageCard",
+			"@context":   "https://schema.org/extensions",
+			"summary":    fmt.Sprintf("%s %s", event.Rule, event.State),
+			"themeColor": color,
+			"title":      fmt.Sprintf("%s %s", event.Rule, event.State),
+			"text":       Message(event),
+		}
+	case PresetNtfy:
+		return []byte(Message(event)), "text/plain; charset=utf-8", headers, nil
+	default:
+		payload = event
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, "", nil, fmt.Errorf("encoding webhook payload: %w", err)
+	}
+	return body, "application/json", headers, nil
+}
+
+// Message describes the event in one line for chat presets, e.g.
+// "🔔 CO2 high firing: Meeting Room 1210 ppm (threshold 1000)".
+func Message(event alert.Event) string {
+	icon := "🔔"
+	if event.State == alert.Resolved {
+		icon = "✅"
+	}
+	value := fmt.Sprintf("%g", event.Value)
+	if event.Unit != "" {
+		value += " " + event.Unit
+	}
+	return fmt.Sprintf("%s %s %s: %s %s (threshold %g)", icon, event.Rule, event.State, event.Location, value, event.Threshold)
+}
+
+// Sign returns the "sha256=<hex>" HMAC-SHA256 signature of the body, as
+// sent in SignatureHeader.
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
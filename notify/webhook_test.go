@@ -0,0 +1,321 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ljagiello/airdash/alert"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testEvent = alert.Event{
+	Rule:       "CO2 high",
+	LocationID: 12345,
+	Location:   "Meeting Room",
+	Metric:     "rco2",
+	Unit:       "ppm",
+	Value:      1210,
+	Threshold:  1000,
+	State:      alert.Firing,
+	Time:       time.Date(2023, 10, 10, 3, 42, 11, 0, time.UTC),
+}
+
+// receivedRequest is a request captured by a test receiver.
+type receivedRequest struct {
+	header http.Header
+	body   string
+}
+
+// newReceiver starts an httptest server that records requests and replies
+// with the given status codes in turn, then 200.
+func newReceiver(t *testing.T, statuses ...int) (*httptest.Server, chan receivedRequest) {
+	t.Helper()
+	received := make(chan receivedRequest, 10)
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		received <- receivedRequest{header: r.Header, body: string(body)}
+		if i := int(calls.Add(1)) - 1; i < len(statuses) {
+			w.WriteHeader(statuses[i])
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, received
+}
+
+// noSleep skips the backoff between retries.
+func noSleep(w *Webhook) {
+	w.sleep = func(context.Context, time.Duration) error { return nil }
+}
+
+func TestWebhookPresets(t *testing.T) {
+	testCases := []struct {
+		name        string
+		preset      string
+		contentType string
+		body        string
+	}{
+		{
+			"generic",
+			"",
+			"application/json",
+			`{"rule":"CO2 high","locationId":12345,"location":"Meeting Room","metric":"rco2","unit":"ppm","value":1210,"threshold":1000,"state":"firing","timestamp":"2023-10-10T03:42:11Z"}`,
+		},
+		{
+			"slack",
+			PresetSlack,
+			"application/json",
+			`{"text":"🔔 CO2 high firing: Meeting Room 1210 ppm (threshold 1000)"}`,
+		},
+		{
+			"discord",
+			PresetDiscord,
+			"application/json",
+			`{"content":"🔔 CO2 high firing: Meeting Room 1210 ppm (threshold 1000)"}`,
+		},
+		{
+			"teams",
+			PresetTeams,
+			"application/json",
+			`{"@context":"https://schema.org/extensions","@type":"MessageCard","summary":"CO2 high firing","text":"🔔 CO2 high firing: Meeting Room 1210 ppm (threshold 1000)","themeColor":"D13438","title":"CO2 high firing"}`,
+		},
+		{
+			"ntfy",
+			PresetNtfy,
+			"text/plain; charset=utf-8",
+			"🔔 CO2 high firing: Meeting Room 1210 ppm (threshold 1000)",
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.name, func(t *testing.T) {
+			srv, received := newReceiver(t)
+			webhook, err := NewWebhook(WebhookConfig{URL: srv.URL, Preset: tC.preset})
+			require.NoError(t, err)
+
+			require.NoError(t, webhook.Notify(context.Background(), testEvent))
+
+			req := <-received
+			assert.Equal(t, tC.contentType, req.header.Get("Content-Type"))
+			assert.Equal(t, UserAgent, req.header.Get("User-Agent"))
+			if tC.contentType == "application/json" {
+				assert.JSONEq(t, tC.body, req.body)
+			} else {
+				assert.Equal(t, tC.body, req.body)
+			}
+			assert.Empty(t, req.header.Get(SignatureHeader))
+		})
+	}
+}
+
+func TestWebhookNtfyHeaders(t *testing.T) {
+	srv, received := newReceiver(t)
+	webhook, err := NewWebhook(WebhookConfig{URL: srv.URL, Preset: PresetNtfy})
+	require.NoError(t, err)
+
+	resolved := testEvent
+	resolved.State = alert.Resolved
+	resolved.Value = 780
+	require.NoError(t, webhook.Notify(context.Background(), resolved))
+
+	req := <-received
+	assert.Equal(t, "CO2 high resolved", req.header.Get("Title"))
+	assert.Equal(t, "default", req.header.Get("Priority"))
+	assert.Equal(t, "white_check_mark", req.header.Get("Tags"))
+	assert.Equal(t, "✅ CO2 high resolved: Meeting Room 780 ppm (threshold 1000)", req.body)
+}
+
+func TestWebhookTemplate(t *testing.T) {
+	srv, received := newReceiver(t)
+	webhook, err := NewWebhook(WebhookConfig{
+		URL:      srv.URL,
+		Template: `{"alert":"{{.Rule}}","where":"{{.Location}}","value":{{.Value}},"state":"{{.State}}"}`,
+		Headers:  map[string]string{"Authorization": "Bearer abc"},
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, webhook.Notify(context.Background(), testEvent))
+
+	req := <-received
+	assert.Equal(t, "application/json", req.header.Get("Content-Type"))
+	assert.Equal(t, "Bearer abc", req.header.Get("Authorization"))
+	assert.JSONEq(t, `{"alert":"CO2 high","where":"Meeting Room","value":1210,"state":"firing"}`, req.body)
+}
+
+func TestWebhookSignature(t *testing.T) {
+	srv, received := newReceiver(t)
+	webhook, err := NewWebhook(WebhookConfig{URL: srv.URL, Secret: "s3cret"})
+	require.NoError(t, err)
+
+	require.NoError(t, webhook.Notify(context.Background(), testEvent))
+
+	req := <-received
+	assert.Equal(t, Sign("s3cret", []byte(req.body)), req.header.Get(SignatureHeader))
+	assert.Regexp(t, `^sha256=[0-9a-f]{64}$`, req.header.Get(SignatureHeader))
+
+	var event alert.Event
+	require.NoError(t, json.Unmarshal([]byte(req.body), &event))
+	assert.Equal(t, testEvent.Rule, event.Rule)
+}
+
+func TestWebhookRetries(t *testing.T) {
+	testCases := []struct {
+		name     string
+		statuses []int
+		attempts int
+		status   int
+	}{
+		{"server-error-recovers", []int{500, 502}, 3, 0},
+		{"rate-limited-recovers", []int{429}, 2, 0},
+		{"gives-up", []int{503, 503, 503}, 3, 503},
+		{"client-error-not-retried", []int{400}, 1, 400},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.name, func(t *testing.T) {
+			srv, received := newReceiver(t, tC.statuses...)
+			webhook, err := NewWebhook(WebhookConfig{URL: srv.URL}, noSleep)
+			require.NoError(t, err)
+
+			err = webhook.Notify(context.Background(), testEvent)
+			if tC.status == 0 {
+				require.NoError(t, err)
+			} else {
+				var statusErr *StatusError
+				require.ErrorAs(t, err, &statusErr)
+				assert.Equal(t, tC.status, statusErr.StatusCode)
+			}
+			assert.Len(t, received, tC.attempts)
+		})
+	}
+}
+
+func TestWebhookRetryCancelled(t *testing.T) {
+	srv, received := newReceiver(t, 503)
+	ctx, cancel := context.WithCancel(context.Background())
+	webhook, err := NewWebhook(WebhookConfig{URL: srv.URL}, func(w *Webhook) {
+		w.sleep = func(context.Context, time.Duration) error {
+			cancel()
+			return ctx.Err()
+		}
+	})
+	require.NoError(t, err)
+
+	err = webhook.Notify(ctx, testEvent)
+	require.ErrorIs(t, err, context.Canceled)
+	assert.ErrorContains(t, err, "waiting to retry")
+	assert.Len(t, received, 1)
+}
+
+func TestRetryBackoff(t *testing.T) {
+	retry := Retry{MaxAttempts: 5, Delay: time.Second}
+	testCases := []struct {
+		attempt  int
+		expected time.Duration
+	}{
+		{1, time.Second},
+		{2, 2 * time.Second},
+		{4, 8 * time.Second},
+	}
+	for _, tC := range testCases {
+		t.Run(fmt.Sprint(tC.attempt), func(t *testing.T) {
+			assert.Equal(t, tC.expected, retry.backoff(tC.attempt))
+		})
+	}
+	assert.Positive(t, retry.backoff(100))
+}
+
+func TestWebhookTimeout(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(200 * time.Millisecond):
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	webhook, err := NewWebhook(WebhookConfig{URL: srv.URL, Timeout: 20 * time.Millisecond},
+		WithRetry(Retry{MaxAttempts: 1}))
+	require.NoError(t, err)
+
+	err = webhook.Notify(context.Background(), testEvent)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestWebhookErrorsHideSecret(t *testing.T) {
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	t.Cleanup(failing.Close)
+	closed := httptest.NewServer(http.NotFoundHandler())
+	closed.Close()
+
+	testCases := []struct {
+		name   string
+		server *httptest.Server
+	}{
+		{"status", failing},
+		{"transport", closed},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.name, func(t *testing.T) {
+			webhook, err := NewWebhook(WebhookConfig{URL: tC.server.URL + "/services/T0/B0/SECRET?key=SECRET"},
+				WithRetry(Retry{MaxAttempts: 1}))
+			require.NoError(t, err)
+
+			err = webhook.Notify(context.Background(), testEvent)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tC.server.URL)
+			assert.NotContains(t, err.Error(), "SECRET")
+		})
+	}
+
+	_, err := NewWebhook(WebhookConfig{URL: "ftp://example.com/services/T0/B0/SECRET"})
+	require.ErrorIs(t, err, ErrInvalidWebhook)
+	assert.NotContains(t, err.Error(), "SECRET")
+}
+
+func TestNewWebhookInvalid(t *testing.T) {
+	testCases := []struct {
+		name   string
+		config WebhookConfig
+	}{
+		{"missing-url", WebhookConfig{}},
+		{"not-http", WebhookConfig{URL: "ftp://example.com/hook"}},
+		{"unknown-preset", WebhookConfig{URL: "https://example.com/hook", Preset: "pagerduty"}},
+		{"bad-template", WebhookConfig{URL: "https://example.com/hook", Template: "{{.Rule"}},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.name, func(t *testing.T) {
+			_, err := NewWebhook(tC.config)
+			assert.ErrorIs(t, err, ErrInvalidWebhook)
+		})
+	}
+}
+
+// failingNotifier is a Notifier that always fails.
+type failingNotifier struct{ err error }
+
+func (n failingNotifier) Notify(context.Context, alert.Event) error { return n.err }
+
+func TestMulti(t *testing.T) {
+	srv, received := newReceiver(t)
+	webhook, err := NewWebhook(WebhookConfig{URL: srv.URL})
+	require.NoError(t, err)
+	errBroken := errors.New("broken")
+
+	err = Multi{failingNotifier{errBroken}, webhook}.Notify(context.Background(), testEvent)
+
+	assert.ErrorIs(t, err, errBroken)
+	assert.Len(t, received, 1)
+	assert.NoError(t, Multi{}.Notify(context.Background(), testEvent))
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/ljagiello/airdash/airgradient"
+	"github.com/ljagiello/airdash/config"
+	"github.com/ljagiello/airdash/influx"
+	"github.com/ljagiello/airdash/mqtt"
+	"github.com/ljagiello/airdash/store"
+)
+
+// output receives the measures of every successful update, e.g. an MQTT
+// broker, InfluxDB or the history store.
+type output interface {
+	Publish(ctx context.Context, measures []airgradient.Measures) error
+	Close() error
+}
+
+// publishTimeout bounds a single publish, so a hung output gives up instead
+// of piling up updates.
+const publishTimeout = 30 * time.Second
+
+// publishQueue is how many updates wait for an output before newer ones are
+// dropped.
+const publishQueue = 4
+
+// namedOutput is an output with the name it is logged under. It publishes
+// from its own worker, so a slow output holds up neither the menu nor the
+// other outputs.
+type namedOutput struct {
+	name string
+	output
+	queue chan []airgradient.Measures
+	done  chan struct{}
+}
+
+// startOutput starts the worker publishing to o; closeOutputs stops it.
+func startOutput(name string, o output) namedOutput {
+	n := namedOutput{
+		name:   name,
+		output: o,
+		queue:  make(chan []airgradient.Measures, publishQueue),
+		done:   make(chan struct{}),
+	}
+	go n.run()
+	return n
+}
+
+// run publishes the queued measures until the queue is closed.
+func (o namedOutput) run() {
+	defer close(o.done)
+	for measures := range o.queue {
+		ctx, cancel := context.WithTimeout(context.Background(), publishTimeout)
+		if err := o.Publish(ctx, measures); err != nil {
+			logger.Error("Publishing measures", "output", o.name, "error", err)
+		}
+		cancel()
+	}
+}
+
+// enqueue hands measures to the worker, dropping them when it is behind.
+func (o namedOutput) enqueue(measures []airgradient.Measures) {
+	select {
+	case o.queue <- measures:
+	default:
+		logger.Error("Dropping measures, output is behind", "output", o.name)
+	}
+}
+
+// newOutputs returns the outputs enabled in cfg.
+func newOutputs(cfg *config.Config) ([]namedOutput, error) {
+	var outputs []namedOutput
+	if cfg.History.Enabled {
+		s, err := openStore(cfg)
+		if err != nil {
+			return nil, err
+		}
+		outputs = append(outputs, startOutput("history", s))
+	}
+	if cfg.MQTT.Broker != "" {
+		publisher, err := mqtt.NewPublisher(mqttConfig(cfg.MQTT))
+		if err != nil {
+			closeOutputs(outputs)
+			return nil, err
+		}
+		outputs = append(outputs, startOutput("mqtt", publisher))
+	}
+	if cfg.InfluxDB.URL != "" {
+		writer, err := influx.NewWriter(influx.Config(cfg.InfluxDB))
+		if err != nil {
+			closeOutputs(outputs)
+			return nil, err
+		}
+		outputs = append(outputs, startOutput("influxdb", writer))
+	}
+	return outputs, nil
+}
+
+// mqttConfig builds the publisher config of the mqtt section.
+func mqttConfig(c config.MQTT) mqtt.Config {
+	return mqtt.Config{
+		Broker:        c.Broker,
+		ClientID:      c.ClientID,
+		Username:      c.Username,
+		Password:      c.Password,
+		TopicPrefix:   c.TopicPrefix,
+		QoS:           c.QoS,
+		Retain:        c.Retain,
+		Timeout:       c.Timeout,
+		TLS:           mqtt.TLS(c.TLS),
+		HomeAssistant: mqtt.HomeAssistant(c.HomeAssistant),
+	}
+}
+
+// openStore opens the history store configured in cfg, at the default path
+// unless another one is set. A leading "~/" is the home directory.
+func openStore(cfg *config.Config) (*store.Store, error) {
+	storeConfig := store.Config{Path: cfg.History.Path, Raw: cfg.History.Raw, Hourly: cfg.History.Hourly}
+	if storeConfig.Path == "" {
+		storeConfig.Path = getDefaultStorePath()
+	}
+	if rest, ok := strings.CutPrefix(storeConfig.Path, "~/"); ok {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, fmt.Errorf("expanding history.path: %w", err)
+		}
+		storeConfig.Path = filepath.Join(home, rest)
+	}
+	return store.Open(storeConfig)
+}
+
+// closeOutputs waits for every output to publish what is queued and closes
+// it, logging failures.
+func closeOutputs(outputs []namedOutput) {
+	for _, o := range outputs {
+		close(o.queue)
+		<-o.done
+		if err := o.Close(); err != nil {
+			logger.Error("Closing output", "output", o.name, "error", err)
+		}
+	}
+}
+
+// outputSource hands the measures of the configured locations to every
+// output after each successful fetch.
+type outputSource struct {
+	source    airgradient.Source
+	outputs   []namedOutput
+	locations []string
+}
+
+// withOutputs returns source unchanged when there are no outputs.
+func withOutputs(source airgradient.Source, outputs []namedOutput, locations []string) airgradient.Source {
+	if len(outputs) == 0 {
+		return source
+	}
+	return outputSource{source: source, outputs: outputs, locations: locations}
+}
+
+// Current fetches the measures and publishes them to the outputs.
+func (s outputSource) Current(ctx context.Context) ([]airgradient.Measures, error) {
+	measures, err := s.source.Current(ctx)
+	if err != nil {
+		return nil, err
+	}
+	selected := airgradient.SelectLocations(measures, s.locations)
+	for _, o := range s.outputs {
+		o.enqueue(selected)
+	}
+	return measures, nil
+}
@@ -0,0 +1,374 @@
+// Package render formats AirGradient measures for status lines.
+package render
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/ljagiello/airdash/airgradient"
+	"github.com/ljagiello/airdash/aqi"
+	"github.com/ljagiello/airdash/classify"
+	"github.com/ljagiello/airdash/config"
+)
+
+// Options control how measures are rendered.
+type Options struct {
+	// Aggregate is config.AggregateWorst or config.AggregateMean.
+	Aggregate string
+	// TempUnit is "C" or "F".
+	TempUnit string
+	// AQI is config.AQIOff, config.AQIInstead or config.AQIAlongside.
+	AQI string
+	// Standard is the index shown, the US EPA AQI when nil.
+	Standard aqi.Standard
+	// TitleAQI is the index shown in the title, typically the NowCast from
+	// an aqi.Tracker. When nil the index of the current readings is shown.
+	TitleAQI *aqi.Index
+	// Comfort adds the derived comfort metrics of the title's readings.
+	Comfort bool
+	// Title formats the title and location lines instead of the built-in
+	// format when set.
+	Title *TitleTemplate
+	// Levels classifies the readings, with the default bands when nil.
+	Levels *classify.Classifier
+	// Now is the time the age of the readings is measured at, the current
+	// time when zero.
+	Now time.Time
+	// MaxAge is how old readings can be before they are stale, e.g. from a
+	// monitor that went offline while the API keeps returning its last
+	// readings. Zero never marks readings stale.
+	MaxAge time.Duration
+}
+
+// StatusView is what the menu bar shows: a title plus, when more than one
+// location is selected, one breakdown line per location. AQI and Comfort
+// describe the title's readings when they are enabled. The title leaves out
+// stale locations, and Stale is set when every location is stale.
+type StatusView struct {
+	Title     string
+	AQI       string
+	Comfort   string
+	Locations []string
+	Stale     bool
+}
+
+// BuildStatusView builds the menu bar model from the selected measures.
+func BuildStatusView(measures []airgradient.Measures, opts Options) StatusView {
+	var view StatusView
+	if len(measures) == 0 {
+		return view
+	}
+
+	title := FreshMeasures(measures, opts)
+	if len(title) == 0 {
+		// Every location is offline, so the title shows their last readings
+		title = measures
+	}
+	agg := AggregateMeasures(title, opts.Aggregate)
+	view.Title = formatTitle(agg, len(title), opts)
+	view.Stale = IsStale(agg, opts)
+	if index, ok := titleAQI(agg, opts); ok && showAQI(opts.AQI) {
+		view.AQI = FormatAQI(opts.standard(), index)
+	}
+	if opts.Comfort {
+		view.Comfort = FormatComfort(agg, opts.TempUnit)
+	}
+	if len(measures) > 1 {
+		// Per-location lines use the AQI of their current readings
+		locationOpts := opts
+		locationOpts.TitleAQI = nil
+		for _, m := range measures {
+			view.Locations = append(view.Locations, fmt.Sprintf("%s  %s", LocationLabel(m), FormatTitle(m, locationOpts)))
+		}
+	}
+	return view
+}
+
+// FormatTitle formats the measures of a location for the status bar, with
+// opts.Title or the built-in format. Missing readings are shown as "—".
+func FormatTitle(m airgradient.Measures, opts Options) string {
+	return formatTitle(m, 1, opts)
+}
+
+// formatTitle formats measures covering the given number of locations.
+// Stale readings are prefixed with their age when it is known, e.g. "⏳2h ".
+func formatTitle(m airgradient.Measures, locations int, opts Options) string {
+	title := formatReadings(m, locations, opts)
+	switch {
+	case !IsStale(m, opts):
+		return title
+	case m.Timestamp.IsZero():
+		return "⏳ " + title
+	default:
+		return "⏳" + FormatDuration(opts.now().Sub(m.Timestamp)) + " " + title
+	}
+}
+
+func formatReadings(m airgradient.Measures, locations int, opts Options) string {
+	if opts.Title != nil {
+		title, err := opts.Title.Execute(NewTitleData(m, locations, opts))
+		if err != nil {
+			return "⚠️ title template"
+		}
+		return title
+	}
+	return fmt.Sprintf("🌡️ %s  💨 %s  💧 %s  🫧 %s",
+		FormatValue(ConvertTemperatureValue(m.Atmp, opts.TempUnit), 2),
+		formatParticulates(m, opts),
+		FormatValue(m.Rhum, 1),
+		FormatValue(m.Rco2, 0),
+	)
+}
+
+// formatParticulates formats the PM2.5 part of the title according to
+// opts.AQI.
+func formatParticulates(m airgradient.Measures, opts Options) string {
+	pm := FormatValue(m.Pm02, 0)
+	if !showAQI(opts.AQI) {
+		return pm
+	}
+
+	label := opts.standard().Label()
+	index := label + " —"
+	if i, ok := titleAQI(m, opts); ok {
+		index = fmt.Sprintf("%s %d", label, i.Value)
+	}
+	if opts.AQI == config.AQIInstead {
+		return index
+	}
+	return fmt.Sprintf("%s (%s)", pm, index)
+}
+
+func showAQI(mode string) bool {
+	return mode == config.AQIInstead || mode == config.AQIAlongside
+}
+
+func titleAQI(m airgradient.Measures, opts Options) (aqi.Index, bool) {
+	if opts.TitleAQI != nil {
+		return *opts.TitleAQI, true
+	}
+	return opts.standard().Index(m.Pm02, m.Pm10)
+}
+
+func (o Options) standard() aqi.Standard {
+	if o.Standard == nil {
+		return aqi.USEPA
+	}
+	return o.Standard
+}
+
+// IsStale reports whether the readings of m are older than opts.MaxAge.
+// Readings without a timestamp are stale, as their age is unknown.
+func IsStale(m airgradient.Measures, opts Options) bool {
+	return opts.MaxAge > 0 && (m.Timestamp.IsZero() || opts.now().Sub(m.Timestamp) > opts.MaxAge)
+}
+
+// FreshMeasures returns the measures that are not stale, e.g. to aggregate
+// or track only the locations that are online.
+func FreshMeasures(measures []airgradient.Measures, opts Options) []airgradient.Measures {
+	var fresh []airgradient.Measures
+	for _, m := range measures {
+		if !IsStale(m, opts) {
+			fresh = append(fresh, m)
+		}
+	}
+	return fresh
+}
+
+func (o Options) now() time.Time {
+	if o.Now.IsZero() {
+		return time.Now()
+	}
+	return o.Now
+}
+
+// FormatAQI describes an index for the menu, e.g. "AQI 56 · Moderate (PM2.5)".
+func FormatAQI(standard aqi.Standard, index aqi.Index) string {
+	return fmt.Sprintf("%s %d · %s (%s)", standard.Label(), index.Value, index.Category.Name, index.Pollutant)
+}
+
+// FormatValue formats a reading with the given number of decimals, or "—"
+// when it is missing.
+func FormatValue(v airgradient.Value, decimals int) string {
+	if !v.Valid {
+		return "—"
+	}
+	return strconv.FormatFloat(v.Float64, 'f', decimals, 64)
+}
+
+// FormatComfort formats the derived comfort metrics for the menu, e.g.
+// "Dew point 9.3°C · Heat index 19.4°C · Humidex 21 · Abs. humidity 8.6 g/m³
+// · Wet bulb 13.7°C". It returns "" when temperature or humidity is missing.
+func FormatComfort(m airgradient.Measures, tempUnit string) string {
+	if !m.DewPoint().Valid {
+		return ""
+	}
+	unit := TemperatureUnit(tempUnit)
+	return fmt.Sprintf("Dew point %s%s · Heat index %s%s · Humidex %s · Abs. humidity %s g/m³ · Wet bulb %s%s",
+		FormatValue(ConvertTemperatureValue(m.DewPoint(), tempUnit), 1), unit,
+		FormatValue(ConvertTemperatureValue(m.HeatIndex(), tempUnit), 1), unit,
+		FormatValue(m.Humidex(), 0),
+		FormatValue(m.AbsoluteHumidity(), 1),
+		FormatValue(ConvertTemperatureValue(m.WetBulb(), tempUnit), 1), unit,
+	)
+}
+
+// LocationLabel returns the location name, falling back to its ID.
+func LocationLabel(m airgradient.Measures) string {
+	if m.LocationName != "" {
+		return m.LocationName
+	}
+	return fmt.Sprintf("Location %d", m.LocationID)
+}
+
+// AggregateMeasures combines several locations into one set of measures.
+// With config.AggregateWorst the pollutant readings are the maximum across
+// locations, with config.AggregateMean they are averaged. Temperature and
+// humidity have no "worse" direction and are always averaged. The timestamp is the
+// newest one; leave stale locations out with FreshMeasures so the aggregate
+// does not look fresher than its inputs.
+func AggregateMeasures(measures []airgradient.Measures, mode string) airgradient.Measures {
+	if len(measures) == 1 {
+		return measures[0]
+	}
+
+	var agg airgradient.Measures
+	if len(measures) == 0 {
+		return agg
+	}
+
+	pollutant := func(get func(airgradient.Measures) airgradient.Value) airgradient.Value {
+		if mode == config.AggregateMean {
+			return mean(measures, get)
+		}
+		return worst(measures, get)
+	}
+
+	agg.Pm01 = pollutant(func(m airgradient.Measures) airgradient.Value { return m.Pm01 })
+	agg.Pm02 = pollutant(func(m airgradient.Measures) airgradient.Value { return m.Pm02 })
+	agg.Pm10 = pollutant(func(m airgradient.Measures) airgradient.Value { return m.Pm10 })
+	agg.Pm003Count = pollutant(func(m airgradient.Measures) airgradient.Value { return m.Pm003Count })
+	agg.Rco2 = pollutant(func(m airgradient.Measures) airgradient.Value { return m.Rco2 })
+	agg.Tvoc = pollutant(func(m airgradient.Measures) airgradient.Value { return m.Tvoc })
+	agg.TvocIndex = pollutant(func(m airgradient.Measures) airgradient.Value { return m.TvocIndex })
+	agg.NoxIndex = pollutant(func(m airgradient.Measures) airgradient.Value { return m.NoxIndex })
+	agg.Atmp = mean(measures, func(m airgradient.Measures) airgradient.Value { return m.Atmp })
+	agg.Rhum = mean(measures, func(m airgradient.Measures) airgradient.Value { return m.Rhum })
+	agg.Timestamp = newestTimestamp(measures)
+
+	return agg
+}
+
+// mean averages the present readings; it is missing if every reading is.
+func mean(measures []airgradient.Measures, get func(airgradient.Measures) airgradient.Value) airgradient.Value {
+	var sum float64
+	var n int
+	for _, m := range measures {
+		if v := get(m); v.Valid {
+			sum += v.Float64
+			n++
+		}
+	}
+	if n == 0 {
+		return airgradient.Value{}
+	}
+	return airgradient.NewValue(sum / float64(n))
+}
+
+// worst returns the highest present reading; it is missing if every reading is.
+func worst(measures []airgradient.Measures, get func(airgradient.Measures) airgradient.Value) airgradient.Value {
+	var highest airgradient.Value
+	for _, m := range measures {
+		if v := get(m); v.Valid && (!highest.Valid || v.Float64 > highest.Float64) {
+			highest = v
+		}
+	}
+	return highest
+}
+
+func newestTimestamp(measures []airgradient.Measures) time.Time {
+	newest := measures[0].Timestamp
+	for _, m := range measures[1:] {
+		if m.Timestamp.After(newest) {
+			newest = m.Timestamp
+		}
+	}
+	return newest
+}
+
+// ConvertTemperatureValue converts a temperature reading like
+// ConvertTemperature, keeping a missing reading missing.
+func ConvertTemperatureValue(temperature airgradient.Value, tempUnit string) airgradient.Value {
+	if !temperature.Valid {
+		return temperature
+	}
+	return airgradient.NewValue(ConvertTemperature(temperature.Float64, tempUnit))
+}
+
+// TemperatureUnit returns the symbol of the temperature unit, "°C" by
+// default.
+func TemperatureUnit(tempUnit string) string {
+	if tempUnit == "F" {
+		return "°F"
+	}
+	return "°C"
+}
+
+// ConvertMetrics returns the metrics with their temperatures converted to
+// the given unit.
+func ConvertMetrics(metrics []airgradient.Metric, tempUnit string) []airgradient.Metric {
+	converted := make([]airgradient.Metric, len(metrics))
+	for i, metric := range metrics {
+		if metric.Temperature {
+			value := metric.Value
+			metric.Unit = TemperatureUnit(tempUnit)
+			metric.Value = func(m airgradient.Measures) airgradient.Value {
+				return ConvertTemperatureValue(value(m), tempUnit)
+			}
+		}
+		converted[i] = metric
+	}
+	return converted
+}
+
+// ConvertTemperature converts the temperature from Celsius to Fahrenheit if the
+// temperature unit is set to Fahrenheit.
+// By default the temperature unit is Celsius.
+func ConvertTemperature(temperature float64, tempUnit string) float64 {
+	if tempUnit == "F" {
+		return (temperature * 9 / 5) + 32
+	}
+	return temperature
+}
+
+// ErrorState returns a short, human-readable description of a failed update
+// for the status bar, e.g. "token invalid".
+func ErrorState(err error) string {
+	var urlErr *url.Error
+	switch {
+	case err == nil:
+		return ""
+	case errors.Is(err, airgradient.ErrUnauthorized):
+		return "token invalid"
+	case errors.Is(err, airgradient.ErrNotFound):
+		return "location not found"
+	case errors.Is(err, airgradient.ErrRateLimited):
+		return "rate limited"
+	case errors.Is(err, airgradient.ErrUnavailable):
+		return "API unavailable"
+	case errors.Is(err, airgradient.ErrBadPayload):
+		return "bad API response"
+	case errors.As(err, &urlErr):
+		return "offline"
+	default:
+		return "error"
+	}
+}
+
+// FormatErrorTitle formats a failed update for the status bar.
+func FormatErrorTitle(err error) string {
+	return "⚠️ " + ErrorState(err)
+}
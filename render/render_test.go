@@ -0,0 +1,310 @@
+package render
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/ljagiello/airdash/airgradient"
+	"github.com/ljagiello/airdash/aqi"
+	"github.com/ljagiello/airdash/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAggregateMeasures(t *testing.T) {
+	v := airgradient.NewValue
+	older := time.Date(2023, 10, 10, 3, 41, 5, 0, time.UTC)
+	newer := time.Date(2023, 10, 10, 3, 42, 11, 0, time.UTC)
+	measures := []airgradient.Measures{
+		{LocationID: 12345, Pm02: v(4), Rco2: v(548), Atmp: v(24), Rhum: v(52), Timestamp: newer},
+		{LocationID: 23456, Pm02: v(12), Rco2: v(1210), Atmp: v(22), Rhum: v(48), Timestamp: older},
+		{LocationID: 34567, Pm01: v(3), Timestamp: newer},
+	}
+
+	testCases := []struct {
+		name     string
+		mode     string
+		expected airgradient.Measures
+	}{
+		{
+			"worst",
+			config.AggregateWorst,
+			airgradient.Measures{Pm01: v(3), Pm02: v(12), Rco2: v(1210), Atmp: v(23), Rhum: v(50), Timestamp: newer},
+		},
+		{
+			"mean",
+			config.AggregateMean,
+			airgradient.Measures{Pm01: v(3), Pm02: v(8), Rco2: v(879), Atmp: v(23), Rhum: v(50), Timestamp: newer},
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.name, func(t *testing.T) {
+			assert.Equal(t, tC.expected, AggregateMeasures(measures, tC.mode))
+		})
+	}
+}
+
+func TestBuildStatusView(t *testing.T) {
+	v := airgradient.NewValue
+	single := []airgradient.Measures{
+		{LocationID: 12345, LocationName: "Test Loc", Pm02: v(4), Rco2: v(548), Atmp: v(20), Rhum: v(52)},
+	}
+	multiple := append([]airgradient.Measures{
+		{LocationID: 23456, Pm02: v(12), Rco2: v(1210), Atmp: v(20), Rhum: v(48)},
+	}, single...)
+
+	testCases := []struct {
+		name     string
+		measures []airgradient.Measures
+		tempUnit string
+		expected StatusView
+	}{
+		{
+			"no-measures",
+			nil,
+			"C",
+			StatusView{},
+		},
+		{
+			"single-location",
+			single,
+			"F",
+			StatusView{Title: "🌡️ 68.00  💨 4  💧 52.0  🫧 548"},
+		},
+		{
+			"missing-values",
+			[]airgradient.Measures{{LocationID: 12345, Rco2: v(548)}},
+			"C",
+			StatusView{Title: "🌡️ —  💨 —  💧 —  🫧 548"},
+		},
+		{
+			"multiple-locations",
+			multiple,
+			"C",
+			StatusView{
+				Title: "🌡️ 20.00  💨 12  💧 50.0  🫧 1210",
+				Locations: []string{
+					"Location 23456  🌡️ 20.00  💨 12  💧 48.0  🫧 1210",
+					"Test Loc  🌡️ 20.00  💨 4  💧 52.0  🫧 548",
+				},
+			},
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.name, func(t *testing.T) {
+			assert.Equal(t, tC.expected, BuildStatusView(tC.measures, Options{Aggregate: config.AggregateWorst, TempUnit: tC.tempUnit}))
+		})
+	}
+}
+
+func TestBuildStatusViewAQI(t *testing.T) {
+	v := airgradient.NewValue
+	single := []airgradient.Measures{
+		{LocationID: 12345, Pm02: v(12), Rco2: v(548), Atmp: v(20), Rhum: v(52)},
+	}
+	multiple := append([]airgradient.Measures{
+		{LocationID: 23456, Pm02: v(40), Rco2: v(1210), Atmp: v(20), Rhum: v(48)},
+	}, single...)
+	nowCast := aqi.FromPM25(28)
+	daqi, err := aqi.Lookup(aqi.StandardUKDAQI)
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name     string
+		measures []airgradient.Measures
+		opts     Options
+		expected StatusView
+	}{
+		{
+			"off",
+			single,
+			Options{AQI: config.AQIOff},
+			StatusView{Title: "🌡️ 20.00  💨 12  💧 52.0  🫧 548"},
+		},
+		{
+			"instead",
+			single,
+			Options{AQI: config.AQIInstead},
+			StatusView{Title: "🌡️ 20.00  💨 AQI 56  💧 52.0  🫧 548", AQI: "AQI 56 · Moderate (PM2.5)"},
+		},
+		{
+			"alongside",
+			single,
+			Options{AQI: config.AQIAlongside},
+			StatusView{Title: "🌡️ 20.00  💨 12 (AQI 56)  💧 52.0  🫧 548", AQI: "AQI 56 · Moderate (PM2.5)"},
+		},
+		{
+			"other-standard",
+			single,
+			Options{AQI: config.AQIAlongside, Standard: daqi},
+			StatusView{Title: "🌡️ 20.00  💨 12 (DAQI 2)  💧 52.0  🫧 548", AQI: "DAQI 2 · Low (PM2.5)"},
+		},
+		{
+			"missing-pm",
+			[]airgradient.Measures{{LocationID: 12345, Rco2: v(548)}},
+			Options{AQI: config.AQIInstead},
+			StatusView{Title: "🌡️ —  💨 AQI —  💧 —  🫧 548"},
+		},
+		{
+			"nowcast-title",
+			multiple,
+			Options{Aggregate: config.AggregateWorst, AQI: config.AQIInstead, TitleAQI: &nowCast},
+			StatusView{
+				Title: "🌡️ 20.00  💨 AQI 86  💧 50.0  🫧 1210",
+				AQI:   "AQI 86 · Moderate (PM2.5)",
+				Locations: []string{
+					"Location 23456  🌡️ 20.00  💨 AQI 112  💧 48.0  🫧 1210",
+					"Location 12345  🌡️ 20.00  💨 AQI 56  💧 52.0  🫧 548",
+				},
+			},
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.name, func(t *testing.T) {
+			assert.Equal(t, tC.expected, BuildStatusView(tC.measures, tC.opts))
+		})
+	}
+}
+
+func TestBuildStatusViewStale(t *testing.T) {
+	v := airgradient.NewValue
+	now := time.Date(2023, 10, 10, 3, 47, 11, 0, time.UTC)
+	fresh := airgradient.Measures{LocationID: 12345, LocationName: "Office", Rco2: v(548), Timestamp: now.Add(-2 * time.Minute)}
+	stale := airgradient.Measures{LocationID: 23456, LocationName: "Bedroom", Rco2: v(1210), Timestamp: now.Add(-2 * time.Hour)}
+	older := airgradient.Measures{LocationID: 34567, LocationName: "Attic", Rco2: v(900), Timestamp: now.Add(-3 * time.Hour)}
+	local := airgradient.Measures{LocationName: "Living Room", Rco2: v(447)}
+
+	testCases := []struct {
+		name     string
+		measures []airgradient.Measures
+		maxAge   time.Duration
+		expected StatusView
+	}{
+		{
+			"fresh",
+			[]airgradient.Measures{fresh},
+			10 * time.Minute,
+			StatusView{Title: "🌡️ —  💨 —  💧 —  🫧 548"},
+		},
+		{
+			"stale",
+			[]airgradient.Measures{stale},
+			10 * time.Minute,
+			StatusView{Title: "⏳2h 🌡️ —  💨 —  💧 —  🫧 1210", Stale: true},
+		},
+		{
+			"disabled",
+			[]airgradient.Measures{stale},
+			0,
+			StatusView{Title: "🌡️ —  💨 —  💧 —  🫧 1210"},
+		},
+		{
+			"no-timestamp",
+			[]airgradient.Measures{local},
+			10 * time.Minute,
+			StatusView{Title: "⏳ 🌡️ —  💨 —  💧 —  🫧 447", Stale: true},
+		},
+		{
+			"one-of-several",
+			[]airgradient.Measures{fresh, stale},
+			10 * time.Minute,
+			StatusView{
+				Title: "🌡️ —  💨 —  💧 —  🫧 548",
+				Locations: []string{
+					"Office  🌡️ —  💨 —  💧 —  🫧 548",
+					"Bedroom  ⏳2h 🌡️ —  💨 —  💧 —  🫧 1210",
+				},
+			},
+		},
+		{
+			"all-of-several",
+			[]airgradient.Measures{stale, older},
+			10 * time.Minute,
+			StatusView{
+				Title: "⏳2h 🌡️ —  💨 —  💧 —  🫧 1210",
+				Locations: []string{
+					"Bedroom  ⏳2h 🌡️ —  💨 —  💧 —  🫧 1210",
+					"Attic  ⏳3h 🌡️ —  💨 —  💧 —  🫧 900",
+				},
+				Stale: true,
+			},
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.name, func(t *testing.T) {
+			opts := Options{Aggregate: config.AggregateWorst, Now: now, MaxAge: tC.maxAge}
+			assert.Equal(t, tC.expected, BuildStatusView(tC.measures, opts))
+		})
+	}
+}
+
+func TestConvertTemp(t *testing.T) {
+	testCases := []struct {
+		name     string
+		temp     float64
+		tempUnit string
+		expected float64
+	}{
+		{
+			"convert-celsius-to-fahrenheit",
+			20,
+			"F",
+			68,
+		},
+		{
+			"no-conversion",
+			20,
+			"C",
+			20,
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.name, func(t *testing.T) {
+			assert.Equal(t, tC.expected, ConvertTemperature(tC.temp, tC.tempUnit))
+		})
+	}
+}
+
+func TestErrorState(t *testing.T) {
+	testCases := []struct {
+		name     string
+		err      error
+		expected string
+	}{
+		{"nil", nil, ""},
+		{"unauthorized", &airgradient.APIError{StatusCode: 401}, "token invalid"},
+		{"forbidden-wrapped", fmt.Errorf("fetching: %w", &airgradient.APIError{StatusCode: 403}), "token invalid"},
+		{"not-found", &airgradient.APIError{StatusCode: 404}, "location not found"},
+		{"rate-limited", &airgradient.APIError{StatusCode: 429}, "rate limited"},
+		{"unavailable", &airgradient.APIError{StatusCode: 503}, "API unavailable"},
+		{"bad-payload", airgradient.ErrBadPayload, "bad API response"},
+		{"network", fmt.Errorf("sending HTTP request: %w", &url.Error{Op: "Get", URL: "x", Err: errors.New("connection refused")}), "offline"},
+		{"other", errors.New("boom"), "error"},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.name, func(t *testing.T) {
+			assert.Equal(t, tC.expected, ErrorState(tC.err))
+		})
+	}
+}
+
+func TestFormatComfort(t *testing.T) {
+	v := airgradient.NewValue
+	m := airgradient.Measures{Atmp: v(20), Rhum: v(50)}
+
+	assert.Equal(t, "Dew point 9.3°C · Heat index 19.4°C · Humidex 21 · Abs. humidity 8.6 g/m³ · Wet bulb 13.7°C", FormatComfort(m, "C"))
+	assert.Equal(t, "Dew point 48.7°F · Heat index 66.8°F · Humidex 21 · Abs. humidity 8.6 g/m³ · Wet bulb 56.7°F", FormatComfort(m, "F"))
+	assert.Empty(t, FormatComfort(airgradient.Measures{Atmp: v(20)}, "C"))
+
+	view := BuildStatusView([]airgradient.Measures{m}, Options{Comfort: true})
+	assert.Equal(t, FormatComfort(m, ""), view.Comfort)
+}
+
+func TestFormatValue(t *testing.T) {
+	assert.Equal(t, "—", FormatValue(airgradient.Value{}, 1))
+	assert.Equal(t, "23.3", FormatValue(airgradient.NewValue(23.26), 1))
+	assert.Equal(t, "0", FormatValue(airgradient.NewValue(0), 0))
+}
@@ -0,0 +1,287 @@
+package render
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+	"unicode/utf8"
+
+	"github.com/ljagiello/airdash/airgradient"
+	"github.com/ljagiello/airdash/classify"
+)
+
+// TitleData is what a title template renders: the readings and derived
+// metrics of a location, or of the aggregate of several, with temperatures
+// in the configured unit. Missing readings have Valid unset.
+type TitleData struct {
+	// Location is the location name, empty for the aggregate of several.
+	Location string
+	// LocationID is the location's ID, 0 for an aggregate or a local
+	// monitor.
+	LocationID int
+	// Locations is the number of locations the readings cover.
+	Locations int
+
+	Pm01       airgradient.Value
+	Pm02       airgradient.Value
+	Pm10       airgradient.Value
+	Pm003Count airgradient.Value
+	Atmp       airgradient.Value
+	Rhum       airgradient.Value
+	Rco2       airgradient.Value
+	Tvoc       airgradient.Value
+	TvocIndex  airgradient.Value
+	NoxIndex   airgradient.Value
+	Wifi       airgradient.Value
+
+	DewPoint         airgradient.Value
+	HeatIndex        airgradient.Value
+	Humidex          airgradient.Value
+	AbsoluteHumidity airgradient.Value
+	WetBulb          airgradient.Value
+
+	// AQI is the index of the configured standard, labelled AQILabel, e.g.
+	// "AQI", and described by AQICategory, e.g. "Moderate".
+	AQI         airgradient.Value
+	AQILabel    string
+	AQICategory string
+
+	// Level is the worst level of the classified readings, e.g. "poor",
+	// or "unknown" when there are none. Levels maps every classified metric
+	// present, e.g. "rco2", to its level.
+	Level  string
+	Levels map[string]string
+
+	// TempUnit is the temperature unit symbol, "°C" or "°F".
+	TempUnit string
+	// Units maps every metric name, e.g. "rco2", to its unit.
+	Units map[string]string
+
+	// Timestamp is when the readings were taken and Age how long ago that
+	// was, zero when unknown. Stale is set when Age is over Options.MaxAge.
+	Timestamp time.Time
+	Age       time.Duration
+	Stale     bool
+}
+
+// NewTitleData builds the template data of measures covering the given
+// number of locations.
+func NewTitleData(m airgradient.Measures, locations int, opts Options) TitleData {
+	temp := func(v airgradient.Value) airgradient.Value { return ConvertTemperatureValue(v, opts.TempUnit) }
+	data := TitleData{
+		LocationID: m.LocationID,
+		Locations:  locations,
+
+		Pm01:       m.Pm01,
+		Pm02:       m.Pm02,
+		Pm10:       m.Pm10,
+		Pm003Count: m.Pm003Count,
+		Atmp:       temp(m.Atmp),
+		Rhum:       m.Rhum,
+		Rco2:       m.Rco2,
+		Tvoc:       m.Tvoc,
+		TvocIndex:  m.TvocIndex,
+		NoxIndex:   m.NoxIndex,
+		Wifi:       m.Wifi,
+
+		DewPoint:         temp(m.DewPoint()),
+		HeatIndex:        temp(m.HeatIndex()),
+		Humidex:          m.Humidex(),
+		AbsoluteHumidity: m.AbsoluteHumidity(),
+		WetBulb:          temp(m.WetBulb()),
+
+		AQILabel:  opts.standard().Label(),
+		TempUnit:  TemperatureUnit(opts.TempUnit),
+		Level:     opts.Levels.Measures(m).String(),
+		Levels:    make(map[string]string, len(classify.Metrics)),
+		Units:     make(map[string]string, len(airgradient.Metrics)),
+		Timestamp: m.Timestamp,
+	}
+	if locations == 1 {
+		data.Location = LocationLabel(m)
+	}
+	if index, ok := titleAQI(m, opts); ok {
+		data.AQI = airgradient.NewValue(float64(index.Value))
+		data.AQICategory = index.Category.Name
+	}
+	for _, metric := range classify.Metrics {
+		if level := opts.Levels.Metric(metric, m); level != classify.Unknown {
+			data.Levels[metric] = level.String()
+		}
+	}
+	for _, metric := range ConvertMetrics(airgradient.Metrics, opts.TempUnit) {
+		data.Units[metric.Name] = metric.Unit
+	}
+	if !m.Timestamp.IsZero() {
+		data.Age = opts.now().Sub(m.Timestamp)
+	}
+	data.Stale = IsStale(m, opts)
+	return data
+}
+
+// TitleTemplate is a parsed titleTemplate, executed with TitleData. Besides
+// the text/template builtins it provides:
+//
+//	round N V       V with N decimals, "—" when missing
+//	pad W S         S right-aligned to W characters, left-aligned when W < 0
+//	icon V E… I…    the icon of V's band: with edges E1 < E2 < … the icons
+//	                I1 below E1, I2 from E1, and so on; "" when V is missing
+//	duration D      D in its largest unit, e.g. "42s", "5m" or "3h"
+//
+// For example:
+//
+//	{{icon .Rco2 1000 2000 "🟢" "🟡" "🔴"}} {{round 0 .Rco2}}ppm  PM2.5 {{.Pm02 | round 0}}
+type TitleTemplate struct {
+	tmpl *template.Template
+}
+
+// ParseTitleTemplate parses and checks a title template, returning nil for
+// an empty one, which keeps the built-in title. A template is checked by
+// executing it with complete and with missing readings, so unknown fields
+// and misused functions are reported here rather than on every update.
+func ParseTitleTemplate(text string) (*TitleTemplate, error) {
+	if text == "" {
+		return nil, nil
+	}
+	tmpl, err := template.New("title").Funcs(templateFuncs).Parse(text)
+	if err != nil {
+		return nil, err
+	}
+
+	t := &TitleTemplate{tmpl: tmpl}
+	sample := airgradient.Measures{
+		LocationID:   12345,
+		LocationName: "Sample",
+		Pm01:         airgradient.NewValue(2),
+		Pm02:         airgradient.NewValue(4),
+		Pm10:         airgradient.NewValue(6),
+		Pm003Count:   airgradient.NewValue(450),
+		Atmp:         airgradient.NewValue(22.4),
+		Rhum:         airgradient.NewValue(48),
+		Rco2:         airgradient.NewValue(612),
+		Tvoc:         airgradient.NewValue(120),
+		TvocIndex:    airgradient.NewValue(100),
+		NoxIndex:     airgradient.NewValue(1),
+		Wifi:         airgradient.NewValue(-55),
+		Timestamp:    time.Now(),
+	}
+	for _, data := range []TitleData{
+		NewTitleData(sample, 1, Options{}),
+		NewTitleData(airgradient.Measures{}, 2, Options{}),
+	} {
+		if _, err := t.Execute(data); err != nil {
+			return nil, err
+		}
+	}
+	return t, nil
+}
+
+// Execute renders the title of data.
+func (t *TitleTemplate) Execute(data TitleData) (string, error) {
+	var b strings.Builder
+	if err := t.tmpl.Execute(&b, data); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+var templateFuncs = template.FuncMap{
+	"round":    roundValue,
+	"pad":      pad,
+	"icon":     icon,
+	"duration": FormatDuration,
+}
+
+// roundValue formats a reading or number with the given number of
+// decimals.
+func roundValue(decimals int, v any) (string, error) {
+	value, err := toValue(v)
+	if err != nil {
+		return "", err
+	}
+	return FormatValue(value, decimals), nil
+}
+
+// pad aligns s to width characters, to the right for a positive width.
+func pad(width int, s any) string {
+	text := fmt.Sprint(s)
+	n := utf8.RuneCountInString(text)
+	switch {
+	case width > n:
+		return strings.Repeat(" ", width-n) + text
+	case -width > n:
+		return text + strings.Repeat(" ", -width-n)
+	default:
+		return text
+	}
+}
+
+// icon picks the icon of the band v falls in from edges followed by one
+// more icon than edges.
+func icon(v any, args ...any) (string, error) {
+	value, err := toValue(v)
+	if err != nil {
+		return "", err
+	}
+
+	var edges []float64
+	var icons []string
+	for _, arg := range args {
+		if s, ok := arg.(string); ok {
+			icons = append(icons, s)
+			continue
+		}
+		if len(icons) > 0 {
+			return "", errors.New("icon: edges must come before icons")
+		}
+		edge, err := toValue(arg)
+		if err != nil {
+			return "", err
+		}
+		edges = append(edges, edge.Float64)
+	}
+	if len(icons) != len(edges)+1 {
+		return "", fmt.Errorf("icon: %d edges need %d icons, got %d", len(edges), len(edges)+1, len(icons))
+	}
+
+	if !value.Valid {
+		return "", nil
+	}
+	band := 0
+	for band < len(edges) && value.Float64 >= edges[band] {
+		band++
+	}
+	return icons[band], nil
+}
+
+// FormatDuration formats d in its largest whole unit, e.g. "5m" for 5m30s.
+func FormatDuration(d time.Duration) string {
+	switch {
+	case d >= 24*time.Hour:
+		return fmt.Sprintf("%dd", d/(24*time.Hour))
+	case d >= time.Hour:
+		return fmt.Sprintf("%dh", d/time.Hour)
+	case d >= time.Minute:
+		return fmt.Sprintf("%dm", d/time.Minute)
+	default:
+		return fmt.Sprintf("%ds", max(d/time.Second, 0))
+	}
+}
+
+// toValue accepts a reading or any number as a template argument.
+func toValue(v any) (airgradient.Value, error) {
+	switch v := v.(type) {
+	case airgradient.Value:
+		return v, nil
+	case float64:
+		return airgradient.NewValue(v), nil
+	case int:
+		return airgradient.NewValue(float64(v)), nil
+	case int64:
+		return airgradient.NewValue(float64(v)), nil
+	default:
+		return airgradient.Value{}, fmt.Errorf("expected a reading or number, got %T", v)
+	}
+}
@@ -0,0 +1,114 @@
+package render
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ljagiello/airdash/airgradient"
+	"github.com/ljagiello/airdash/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTitleTemplate(t *testing.T) {
+	v := airgradient.NewValue
+	now := time.Date(2023, 10, 10, 3, 47, 11, 0, time.UTC)
+	m := airgradient.Measures{
+		LocationID:   12345,
+		LocationName: "Test Loc",
+		Pm02:         v(12.4),
+		Atmp:         v(20),
+		Rhum:         v(52),
+		Rco2:         v(1210),
+		Timestamp:    now.Add(-5 * time.Minute),
+	}
+	opts := Options{TempUnit: "F", Now: now}
+
+	testCases := []struct {
+		name     string
+		template string
+		expected string
+	}{
+		{"round", `{{round 1 .Atmp}}{{.TempUnit}} {{.Pm02 | round 0}}`, "68.0°F 12"},
+		{"missing", `TVOC {{round 0 .TvocIndex}}`, "TVOC —"},
+		{"pad", `[{{pad 6 (round 0 .Rco2)}}][{{pad -6 .Location}}]`, "[  1210][Test Loc]"},
+		{"pad-left", `[{{pad -5 (round 0 .Rhum)}}]`, "[52   ]"},
+		{"icon", `{{icon .Rco2 1000 2000 "🟢" "🟡" "🔴"}}{{icon .Pm02 12.5 "ok" "bad"}}`, "🟡ok"},
+		{"icon-missing", `[{{icon .NoxIndex 100 "ok" "bad"}}]`, "[]"},
+		{"conditional", `{{if and .Rco2.Valid (gt .Rco2.Float64 1000.0)}}⚠️ {{end}}CO2`, "⚠️ CO2"},
+		{"units", `{{round 0 .Rco2}} {{index .Units "rco2"}} · {{round 1 .DewPoint}}{{index .Units "dewPoint"}}`, "1210 ppm · 49.7°F"},
+		{"aqi", `{{.AQILabel}} {{round 0 .AQI}} {{.AQICategory}}`, "AQI 57 Moderate"},
+		{"age", `{{.Location}} {{duration .Age}} ago`, "Test Loc 5m ago"},
+		{"levels", `{{.Level}} CO2 {{index .Levels "rco2"}} RH {{index .Levels "rhum"}}`, "moderate CO2 moderate RH good"},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.name, func(t *testing.T) {
+			tmpl, err := ParseTitleTemplate(tC.template)
+			require.NoError(t, err)
+			opts := opts
+			opts.Title = tmpl
+			assert.Equal(t, tC.expected, FormatTitle(m, opts))
+		})
+	}
+}
+
+func TestParseTitleTemplateInvalid(t *testing.T) {
+	testCases := []struct {
+		name     string
+		template string
+		err      string
+	}{
+		{"syntax", `{{round 0 .Rco2`, "unclosed action"},
+		{"unknown-field", `{{.CO2}}`, "can't evaluate field CO2"},
+		{"unknown-function", `{{fixed 0 .Rco2}}`, `function "fixed" not defined`},
+		{"round-string", `{{round 0 .Location}}`, "expected a reading or number, got string"},
+		{"icon-count", `{{icon .Rco2 1000 "ok"}}`, "icon: 1 edges need 2 icons, got 1"},
+		{"icon-order", `{{icon .Rco2 "ok" 1000 "bad"}}`, "icon: edges must come before icons"},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.name, func(t *testing.T) {
+			_, err := ParseTitleTemplate(tC.template)
+			require.ErrorContains(t, err, tC.err)
+		})
+	}
+}
+
+func TestParseTitleTemplateEmpty(t *testing.T) {
+	tmpl, err := ParseTitleTemplate("")
+	require.NoError(t, err)
+	assert.Nil(t, tmpl)
+}
+
+func TestBuildStatusViewTitleTemplate(t *testing.T) {
+	v := airgradient.NewValue
+	measures := []airgradient.Measures{
+		{LocationID: 12345, LocationName: "Test Loc", Pm02: v(4), Rco2: v(548)},
+		{LocationID: 23456, Pm02: v(12), Rco2: v(1210)},
+	}
+	tmpl, err := ParseTitleTemplate(`{{if .Location}}{{.Location}}{{else}}{{.Locations}} rooms{{end}}: {{round 0 .Rco2}}`)
+	require.NoError(t, err)
+
+	view := BuildStatusView(measures, Options{Aggregate: config.AggregateWorst, Title: tmpl})
+	assert.Equal(t, StatusView{
+		Title:     "2 rooms: 1210",
+		Locations: []string{"Test Loc  Test Loc: 548", "Location 23456  Location 23456: 1210"},
+	}, view)
+}
+
+func TestFormatDuration(t *testing.T) {
+	testCases := []struct {
+		duration time.Duration
+		expected string
+	}{
+		{-time.Second, "0s"},
+		{42 * time.Second, "42s"},
+		{5*time.Minute + 30*time.Second, "5m"},
+		{3 * time.Hour, "3h"},
+		{50 * time.Hour, "2d"},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.expected, func(t *testing.T) {
+			assert.Equal(t, tC.expected, FormatDuration(tC.duration))
+		})
+	}
+}
@@ -0,0 +1,220 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/ljagiello/airdash/airgradient"
+	"github.com/ljagiello/airdash/alert"
+	"github.com/ljagiello/airdash/config"
+	"github.com/ljagiello/airdash/exporter"
+	"github.com/ljagiello/airdash/notify"
+	"github.com/ljagiello/airdash/render"
+)
+
+// runHeadless implements the run subcommand: it does what the menu bar app
+// does without a GUI, polling the source every interval, evaluating alerts,
+// publishing to the outputs and serving metrics when metrics.listen is set,
+// until ctx is done.
+func runHeadless(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("run", flag.ContinueOnError)
+	configPath := fs.String("config", getDefaultConfigPath(), "path to config file")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	cfg, err := loadConfig(*configPath)
+	if err != nil {
+		return fmt.Errorf("loading config %s: %w", *configPath, err)
+	}
+	return serve(ctx, cfg, cfg.Metrics.Listen)
+}
+
+// runExporter implements the exporter subcommand: the run subcommand with
+// metrics always served, on -listen, metrics.listen or
+// exporter.DefaultListen.
+func runExporter(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("exporter", flag.ContinueOnError)
+	configPath := fs.String("config", getDefaultConfigPath(), "path to config file")
+	listen := fs.String("listen", "", "address to serve /metrics on (default: metrics.listen from the config, or "+exporter.DefaultListen+")")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	cfg, err := loadConfig(*configPath)
+	if err != nil {
+		return fmt.Errorf("loading config %s: %w", *configPath, err)
+	}
+	if *listen == "" {
+		*listen = cfg.Metrics.Listen
+	}
+	if *listen == "" {
+		*listen = exporter.DefaultListen
+	}
+	return serve(ctx, cfg, *listen)
+}
+
+// serve polls the configured source until ctx is done, serving metrics on
+// listen unless it is empty.
+func serve(ctx context.Context, cfg *config.Config, listen string) error {
+	if cfg.Interval == 0 {
+		cfg.Interval = 60
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	s, cleanup, err := setup(ctx, cfg, listen)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	var served chan error
+	if s.served != nil {
+		served = make(chan error, 1)
+		go func() {
+			err := <-s.served
+			// Stop polling when the metrics can no longer be served
+			cancel()
+			served <- err
+		}()
+	}
+
+	poll(ctx, s.source, time.Duration(cfg.Interval)*time.Second, func(measures []airgradient.Measures, err error) {
+		if err != nil {
+			logger.Error("Fetching measures", "error", err, "state", render.ErrorState(err))
+			notifyAlerts(s.notifier, s.alerts.Failed())
+			return
+		}
+		notifyAlerts(s.notifier, s.alerts.Evaluate(measures))
+	})
+	if served != nil {
+		return <-served
+	}
+	return nil
+}
+
+// services are what the menu bar app and the run subcommand poll with.
+type services struct {
+	source   airgradient.Source
+	alerts   *alert.Engine
+	notifier notify.Notifier
+	// served receives the error serving metrics stopped with; nil when
+	// metrics are not served.
+	served <-chan error
+}
+
+// setup builds the configured source, publishing to the outputs and
+// instrumented by an exporter serving metrics on listen unless it is empty,
+// along with the alert engine and the notifier. The returned cleanup stops
+// serving metrics and closes the outputs; it is safe to call more than once.
+func setup(ctx context.Context, cfg *config.Config, listen string) (*services, func(), error) {
+	source, err := newSource(cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	alerts, err := newAlerts(cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	notifier, err := newNotifier(cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	levels, err := newLevels(cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	outputs, err := newOutputs(cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	cleanup := sync.OnceFunc(func() {
+		cancel()
+		closeOutputs(outputs)
+	})
+
+	s := &services{alerts: alerts, notifier: notifier}
+	if listen != "" {
+		exp := exporter.New(
+			exporter.WithLocations(cfg.Locations),
+			exporter.WithLevels(levels),
+			exporter.WithMaxAge(cfg.StaleAfter()),
+		)
+		source = exp.Instrument(source)
+		ln, err := net.Listen("tcp", listen)
+		if err != nil {
+			cleanup()
+			return nil, nil, fmt.Errorf("listening for metrics: %w", err)
+		}
+		logger.Info("Serving metrics", "address", ln.Addr().String())
+
+		served := make(chan error, 1)
+		go func() {
+			served <- serveMetrics(ctx, ln, exp)
+		}()
+		s.served = served
+	}
+	s.source = withOutputs(source, outputs, cfg.Locations)
+	return s, cleanup, nil
+}
+
+// poll fetches the measures right away and then every interval until ctx is
+// done, handing every fetch to handle. Fetches cut short by ctx are not
+// handled.
+func poll(ctx context.Context, source airgradient.Source, interval time.Duration, handle func([]airgradient.Measures, error)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		measures, err := source.Current(ctx)
+		if ctx.Err() == nil {
+			handle(measures, err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// notifyAlerts logs the alert events and delivers them in the background, so
+// a slow webhook does not hold up polling.
+func notifyAlerts(notifier notify.Notifier, events []alert.Event) {
+	for _, event := range events {
+		logger.Info("Alert", "rule", event.Rule, "state", event.State, "location", event.Location, "metric", event.Metric, "value", event.Value)
+		go func() {
+			if err := notifier.Notify(context.Background(), event); err != nil {
+				logger.Error("Notifying alert", "rule", event.Rule, "error", err)
+			}
+		}()
+	}
+}
+
+// serveMetrics serves the exporter's /metrics on ln until ctx is done.
+func serveMetrics(ctx context.Context, ln net.Listener, exp *exporter.Exporter) error {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", exp.Handler())
+	srv := &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = srv.Shutdown(shutdownCtx)
+	}()
+
+	if err := srv.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("serving metrics: %w", err)
+	}
+	return nil
+}
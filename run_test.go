@@ -0,0 +1,114 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ljagiello/airdash/airgradient"
+	"github.com/ljagiello/airdash/exporter"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServeMetrics(t *testing.T) {
+	exp := exporter.New()
+	exp.Update([]airgradient.Measures{{LocationID: 12345, LocationName: "Test Loc", Serialno: "aabb12", Rco2: airgradient.NewValue(548)}})
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- serveMetrics(ctx, ln, exp) }()
+
+	resp, err := http.Get("http://" + ln.Addr().String() + "/metrics")
+	require.NoError(t, err)
+	body, err := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Contains(t, string(body), `airgradient_rco2{location_id="12345",location_name="Test Loc",serialno="aabb12"} 548`)
+	assert.Contains(t, string(body), `airdash_fetch_errors_total{type="unauthorized"} 0`)
+
+	cancel()
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("serveMetrics did not return after the context was cancelled")
+	}
+}
+
+// fakeOutput records the measures published to it.
+type fakeOutput struct {
+	published chan []airgradient.Measures
+}
+
+func (o fakeOutput) Publish(_ context.Context, measures []airgradient.Measures) error {
+	o.published <- measures
+	return nil
+}
+
+func (o fakeOutput) Close() error { return nil }
+
+type fakeSource []airgradient.Measures
+
+func (s fakeSource) Current(context.Context) ([]airgradient.Measures, error) {
+	return s, nil
+}
+
+func TestWithOutputs(t *testing.T) {
+	measures := fakeSource{{LocationID: 12345, LocationName: "Test Loc"}, {LocationID: 23456, LocationName: "Meeting Room"}}
+	assert.Equal(t, measures, withOutputs(measures, nil, nil))
+
+	out := fakeOutput{published: make(chan []airgradient.Measures, 1)}
+	outputs := []namedOutput{startOutput("fake", out)}
+	defer closeOutputs(outputs)
+	source := withOutputs(measures, outputs, []string{"Meeting Room"})
+	got, err := source.Current(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []airgradient.Measures(measures), got)
+
+	select {
+	case published := <-out.published:
+		assert.Equal(t, []airgradient.Measures{measures[1]}, published)
+	case <-time.After(5 * time.Second):
+		t.Fatal("measures were not published")
+	}
+}
+
+// blockedOutput counts the updates published to it, each one waiting for
+// release after signalling started.
+type blockedOutput struct {
+	started   chan struct{}
+	release   chan struct{}
+	published *atomic.Int32
+}
+
+func (o blockedOutput) Publish(context.Context, []airgradient.Measures) error {
+	o.started <- struct{}{}
+	<-o.release
+	o.published.Add(1)
+	return nil
+}
+
+func (o blockedOutput) Close() error { return nil }
+
+func TestOutputDropsWhenBehind(t *testing.T) {
+	out := blockedOutput{started: make(chan struct{}, publishQueue+3), release: make(chan struct{}), published: new(atomic.Int32)}
+	outputs := []namedOutput{startOutput("blocked", out)}
+
+	outputs[0].enqueue(nil)
+	<-out.started
+	// The queue fills up behind the blocked update and the rest are dropped
+	for range publishQueue + 2 {
+		outputs[0].enqueue(nil)
+	}
+	close(out.release)
+	closeOutputs(outputs)
+	assert.Equal(t, int32(publishQueue+1), out.published.Load())
+}
@@ -0,0 +1,84 @@
+// Package stats summarises series of AirGradient measures.
+package stats
+
+import (
+	"math"
+	"slices"
+
+	"github.com/ljagiello/airdash/airgradient"
+)
+
+// Summary holds the statistics of one metric over a series.
+type Summary struct {
+	Metric string  `json:"metric" yaml:"metric"`
+	Unit   string  `json:"unit" yaml:"unit"`
+	Count  int     `json:"count" yaml:"count"`
+	Min    float64 `json:"min" yaml:"min"`
+	Max    float64 `json:"max" yaml:"max"`
+	Mean   float64 `json:"mean" yaml:"mean"`
+	P50    float64 `json:"p50" yaml:"p50"`
+	P90    float64 `json:"p90" yaml:"p90"`
+	P95    float64 `json:"p95" yaml:"p95"`
+}
+
+// Summarize returns one summary per metric, in order, typically
+// airgradient.Metrics. Missing readings are skipped and metrics without any
+// reading are left out.
+func Summarize(series []airgradient.Measures, metrics []airgradient.Metric) []Summary {
+	var summaries []Summary
+	for _, metric := range metrics {
+		values := make([]float64, 0, len(series))
+		for _, m := range series {
+			if v := metric.Value(m); v.Valid {
+				values = append(values, v.Float64)
+			}
+		}
+		if len(values) == 0 {
+			continue
+		}
+		summary := Describe(values)
+		summary.Metric = metric.Name
+		summary.Unit = metric.Unit
+		summaries = append(summaries, summary)
+	}
+	return summaries
+}
+
+// Describe computes the statistics of values, which must not be empty.
+func Describe(values []float64) Summary {
+	sorted := slices.Clone(values)
+	slices.Sort(sorted)
+
+	var sum float64
+	for _, v := range sorted {
+		sum += v
+	}
+
+	return Summary{
+		Count: len(sorted),
+		Min:   sorted[0],
+		Max:   sorted[len(sorted)-1],
+		Mean:  sum / float64(len(sorted)),
+		P50:   Percentile(sorted, 50),
+		P90:   Percentile(sorted, 90),
+		P95:   Percentile(sorted, 95),
+	}
+}
+
+// Percentile returns the p-th percentile (0-100) of sorted values, linearly
+// interpolating between the closest ranks. It returns NaN for no values.
+func Percentile(sorted []float64, p float64) float64 {
+	if len(sorted) == 0 {
+		return math.NaN()
+	}
+	rank := p / 100 * float64(len(sorted)-1)
+	lower := int(math.Floor(rank))
+	upper := int(math.Ceil(rank))
+	if lower < 0 {
+		return sorted[0]
+	}
+	if upper >= len(sorted) {
+		return sorted[len(sorted)-1]
+	}
+	return sorted[lower] + (sorted[upper]-sorted[lower])*(rank-float64(lower))
+}
@@ -0,0 +1,72 @@
+package stats
+
+import (
+	"math"
+	"testing"
+
+	"github.com/ljagiello/airdash/airgradient"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPercentile(t *testing.T) {
+	sorted := []float64{10, 20, 30, 40, 50}
+
+	testCases := []struct {
+		name     string
+		p        float64
+		expected float64
+	}{
+		{"p0", 0, 10},
+		{"p50", 50, 30},
+		{"p90", 90, 46},
+		{"p95", 95, 48},
+		{"p100", 100, 50},
+		{"p25-interpolated", 25, 20},
+		{"p10-interpolated", 10, 14},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.name, func(t *testing.T) {
+			assert.InDelta(t, tC.expected, Percentile(sorted, tC.p), 1e-9)
+		})
+	}
+
+	assert.True(t, math.IsNaN(Percentile(nil, 50)))
+	assert.InDelta(t, 7.0, Percentile([]float64{7}, 95), 0)
+}
+
+func TestDescribe(t *testing.T) {
+	assert.Equal(t, Summary{Count: 4, Min: 1, Max: 4, Mean: 2.5, P50: 2.5, P90: 3.7, P95: 3.85}, roundSummary(Describe([]float64{4, 1, 3, 2})))
+}
+
+func TestSummarize(t *testing.T) {
+	v := airgradient.NewValue
+	series := []airgradient.Measures{
+		{Pm02: v(4), Rco2: v(548)},
+		{Pm02: v(6), Rco2: v(612)},
+		{Pm02: v(9), Rco2: v(1024), NoxIndex: v(2)},
+	}
+
+	summaries := Summarize(series, airgradient.Metrics)
+	assert.Equal(t, []Summary{
+		{Metric: "pm02", Unit: "µg/m³", Count: 3, Min: 4, Max: 9, Mean: 6.33, P50: 6, P90: 8.4, P95: 8.7},
+		{Metric: "rco2", Unit: "ppm", Count: 3, Min: 548, Max: 1024, Mean: 728, P50: 612, P90: 941.6, P95: 982.8},
+		{Metric: "noxIndex", Unit: "", Count: 1, Min: 2, Max: 2, Mean: 2, P50: 2, P90: 2, P95: 2},
+	}, roundSummaries(summaries))
+
+	assert.Empty(t, Summarize(nil, airgradient.Metrics))
+}
+
+func roundSummaries(summaries []Summary) []Summary {
+	for i := range summaries {
+		summaries[i] = roundSummary(summaries[i])
+	}
+	return summaries
+}
+
+// roundSummary rounds to two decimals to keep float noise out of comparisons.
+func roundSummary(s Summary) Summary {
+	r := func(f float64) float64 { return math.Round(f*100) / 100 }
+	s.Min, s.Max, s.Mean = r(s.Min), r(s.Max), r(s.Mean)
+	s.P50, s.P90, s.P95 = r(s.P50), r(s.P90), r(s.P95)
+	return s
+}
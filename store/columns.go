@@ -0,0 +1,144 @@
+package store
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ljagiello/airdash/airgradient"
+)
+
+// textColumns, numberColumns and valueColumns map the fields of Measures to
+// table columns. Derived metrics are not stored, they follow from the
+// stored temperature and humidity.
+var (
+	textColumns = []struct {
+		name  string
+		field func(*airgradient.Measures) *string
+	}{
+		{"location_name", func(m *airgradient.Measures) *string { return &m.LocationName }},
+		{"serialno", func(m *airgradient.Measures) *string { return &m.Serialno }},
+		{"firmware_version", func(m *airgradient.Measures) *string { return &m.FirmwareVersion }},
+		{"model", func(m *airgradient.Measures) *string { return &m.Model }},
+		{"led_mode", func(m *airgradient.Measures) *string { return &m.LedMode }},
+		{"pm02_correction", func(m *airgradient.Measures) *string { return &m.Pm02Correction }},
+	}
+	numberColumns = []struct {
+		name  string
+		field func(*airgradient.Measures) *float64
+	}{
+		{"led_co2_threshold1", func(m *airgradient.Measures) *float64 { return &m.LedCo2Threshold1 }},
+		{"led_co2_threshold2", func(m *airgradient.Measures) *float64 { return &m.LedCo2Threshold2 }},
+		{"led_co2_threshold_end", func(m *airgradient.Measures) *float64 { return &m.LedCo2ThresholdEnd }},
+	}
+	// valueColumns are NULL for missing readings, which AVG skips
+	valueColumns = []struct {
+		name  string
+		field func(*airgradient.Measures) *airgradient.Value
+	}{
+		{"pm01", func(m *airgradient.Measures) *airgradient.Value { return &m.Pm01 }},
+		{"pm02", func(m *airgradient.Measures) *airgradient.Value { return &m.Pm02 }},
+		{"pm10", func(m *airgradient.Measures) *airgradient.Value { return &m.Pm10 }},
+		{"pm003_count", func(m *airgradient.Measures) *airgradient.Value { return &m.Pm003Count }},
+		{"atmp", func(m *airgradient.Measures) *airgradient.Value { return &m.Atmp }},
+		{"rhum", func(m *airgradient.Measures) *airgradient.Value { return &m.Rhum }},
+		{"rco2", func(m *airgradient.Measures) *airgradient.Value { return &m.Rco2 }},
+		{"tvoc", func(m *airgradient.Measures) *airgradient.Value { return &m.Tvoc }},
+		{"tvoc_index", func(m *airgradient.Measures) *airgradient.Value { return &m.TvocIndex }},
+		{"nox_index", func(m *airgradient.Measures) *airgradient.Value { return &m.NoxIndex }},
+		{"wifi", func(m *airgradient.Measures) *airgradient.Value { return &m.Wifi }},
+		{"pm02_raw", func(m *airgradient.Measures) *airgradient.Value { return &m.Pm02Raw }},
+	}
+)
+
+// Statements built from the column lists.
+var (
+	insertSQL       string
+	downsampleSQL   string
+	selectSQL       string
+	selectHourlySQL string
+)
+
+func init() {
+	var names, aggregates, merges []string
+	for _, c := range textColumns {
+		names = append(names, c.name)
+		aggregates = append(aggregates, "MAX("+c.name+")")
+		merges = append(merges, fmt.Sprintf("%[1]s = excluded.%[1]s", c.name))
+	}
+	for _, c := range numberColumns {
+		names = append(names, c.name)
+		aggregates = append(aggregates, "MAX("+c.name+")")
+		merges = append(merges, fmt.Sprintf("%[1]s = excluded.%[1]s", c.name))
+	}
+	for _, c := range valueColumns {
+		names = append(names, c.name)
+		aggregates = append(aggregates, "AVG("+c.name+")")
+		// Readings arriving for an hour already averaged are weighed in
+		merges = append(merges, fmt.Sprintf(
+			"%[1]s = CASE WHEN excluded.%[1]s IS NULL THEN %[1]s WHEN %[1]s IS NULL THEN excluded.%[1]s "+
+				"ELSE (%[1]s * samples + excluded.%[1]s * excluded.samples) / (samples + excluded.samples) END", c.name,
+		))
+	}
+	columns := strings.Join(names, ", ")
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(names)+2), ", ")
+	hour := "timestamp / 3600 * 3600"
+	where := "WHERE location_id = ? AND timestamp >= ? AND timestamp < ?"
+
+	insertSQL = fmt.Sprintf("INSERT INTO measures (location_id, timestamp, %s) VALUES (%s) ON CONFLICT (location_id, timestamp) DO NOTHING",
+		columns, placeholders)
+	downsampleSQL = fmt.Sprintf("INSERT INTO measures_hourly (location_id, timestamp, samples, %s) "+
+		"SELECT location_id, %s AS hour, COUNT(*), %s FROM measures WHERE timestamp < ? GROUP BY location_id, hour "+
+		"ON CONFLICT (location_id, timestamp) DO UPDATE SET %s, samples = samples + excluded.samples",
+		columns, hour, strings.Join(aggregates, ", "), strings.Join(merges, ", "))
+	selectSQL = fmt.Sprintf("SELECT location_id, timestamp, %[1]s FROM measures %[2]s "+
+		"UNION ALL SELECT location_id, timestamp, %[1]s FROM measures_hourly %[2]s ORDER BY 2",
+		columns, where)
+	selectHourlySQL = fmt.Sprintf("SELECT location_id, %[3]s AS hour, %[4]s FROM measures %[2]s GROUP BY location_id, hour "+
+		"UNION ALL SELECT location_id, timestamp, %[1]s FROM measures_hourly %[2]s ORDER BY 2",
+		columns, where, hour, strings.Join(aggregates, ", "))
+}
+
+// rowValues returns the values of a row in column order.
+func rowValues(m airgradient.Measures, timestamp int64) []any {
+	values := []any{m.LocationID, timestamp}
+	for _, c := range textColumns {
+		values = append(values, *c.field(&m))
+	}
+	for _, c := range numberColumns {
+		values = append(values, *c.field(&m))
+	}
+	for _, c := range valueColumns {
+		values = append(values, c.field(&m).Ptr())
+	}
+	return values
+}
+
+// scanRow reads a row selected in column order.
+func scanRow(rows *sql.Rows) (airgradient.Measures, error) {
+	var m airgradient.Measures
+	var timestamp int64
+	nulls := make([]sql.NullFloat64, len(valueColumns))
+	dest := []any{&m.LocationID, &timestamp}
+	for _, c := range textColumns {
+		dest = append(dest, c.field(&m))
+	}
+	for _, c := range numberColumns {
+		dest = append(dest, c.field(&m))
+	}
+	for i := range nulls {
+		dest = append(dest, &nulls[i])
+	}
+	if err := rows.Scan(dest...); err != nil {
+		return m, err
+	}
+
+	m.Timestamp = time.Unix(timestamp, 0).UTC()
+	for i, c := range valueColumns {
+		if nulls[i].Valid {
+			*c.field(&m) = airgradient.NewValue(nulls[i].Float64)
+		}
+	}
+	return m, nil
+}
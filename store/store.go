@@ -0,0 +1,287 @@
+// Package store keeps a local history of measures in SQLite, with raw
+// readings for a week and hourly averages for a year by default.
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ljagiello/airdash/airgradient"
+	_ "modernc.org/sqlite" // Pure Go SQLite driver, so the store builds without cgo
+)
+
+const (
+	// DefaultRawRetention is how long every reading is kept.
+	DefaultRawRetention = 7 * 24 * time.Hour
+	// DefaultHourlyRetention is how long the hourly averages of older
+	// readings are kept.
+	DefaultHourlyRetention = 365 * 24 * time.Hour
+	// compactInterval is how often Publish downsamples and prunes.
+	compactInterval = time.Hour
+)
+
+// Config is the history store as configured in config.yaml:
+//
+//	history:
+//	  enabled: true
+//	  path: /var/lib/airdash/history.db
+//	  raw: 168h
+//	  hourly: 8760h
+type Config struct {
+	// Path is the database file. It is used as given, without expanding
+	// "~".
+	Path string `yaml:"path"`
+	// Raw is how long every reading is kept, DefaultRawRetention by
+	// default.
+	Raw time.Duration `yaml:"raw"`
+	// Hourly is how long hourly averages are kept, DefaultHourlyRetention
+	// by default.
+	Hourly time.Duration `yaml:"hourly"`
+}
+
+// Store is a SQLite database of measures. Readings are unique per location
+// and timestamp, so storing the same reading twice keeps one copy. It is
+// safe for concurrent use.
+type Store struct {
+	db     *sql.DB
+	raw    time.Duration
+	hourly time.Duration
+	now    func() time.Time
+
+	mu          sync.Mutex
+	lastCompact time.Time
+}
+
+// Option configures a Store.
+type Option func(*Store)
+
+// WithClock sets the clock retention is measured from, time.Now by default.
+func WithClock(now func() time.Time) Option {
+	return func(s *Store) {
+		s.now = now
+	}
+}
+
+// Open opens the database of config.Path, creating it and its directory if
+// needed, and migrates it to the current schema.
+func Open(config Config, opts ...Option) (*Store, error) {
+	if config.Path == "" {
+		return nil, errors.New("history store needs a path")
+	}
+	if err := os.MkdirAll(filepath.Dir(config.Path), 0o700); err != nil {
+		return nil, err
+	}
+
+	// One connection serializes writers within the process, the busy
+	// timeout waits for other processes such as a concurrent history command
+	dsn := "file:" + config.Path + "?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)"
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("opening history store %s: %w", config.Path, err)
+	}
+	db.SetMaxOpenConns(1)
+
+	s := &Store{
+		db:     db,
+		raw:    config.Raw,
+		hourly: config.Hourly,
+		now:    time.Now,
+	}
+	if s.raw <= 0 {
+		s.raw = DefaultRawRetention
+	}
+	if s.hourly <= 0 {
+		s.hourly = DefaultHourlyRetention
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	if err := migrate(context.Background(), db); err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("migrating history store %s: %w", config.Path, err)
+	}
+	return s, nil
+}
+
+// Close closes the database.
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// Insert stores the measures and returns how many were new. Measures
+// without a timestamp cannot be keyed and are skipped.
+func (s *Store) Insert(ctx context.Context, measures []airgradient.Measures) (int, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	stmt, err := tx.PrepareContext(ctx, insertSQL)
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = stmt.Close() }()
+
+	inserted := 0
+	for _, m := range measures {
+		if m.Timestamp.IsZero() {
+			continue
+		}
+		result, err := stmt.ExecContext(ctx, rowValues(m, m.Timestamp.Unix())...)
+		if err != nil {
+			return 0, fmt.Errorf("storing measures of location %d: %w", m.LocationID, err)
+		}
+		if n, err := result.RowsAffected(); err == nil {
+			inserted += int(n)
+		}
+	}
+	return inserted, tx.Commit()
+}
+
+// Publish stores the measures and, at most once an hour, applies the
+// retention policy.
+func (s *Store) Publish(ctx context.Context, measures []airgradient.Measures) error {
+	if _, err := s.Insert(ctx, measures); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	due := s.now().Sub(s.lastCompact) >= compactInterval
+	if due {
+		s.lastCompact = s.now()
+	}
+	s.mu.Unlock()
+	if !due {
+		return nil
+	}
+	return s.Compact(ctx)
+}
+
+// Compact averages the readings of every complete hour older than the raw
+// retention into the hourly table, deletes those readings and deletes the
+// hourly averages older than the hourly retention.
+func (s *Store) Compact(ctx context.Context) error {
+	now := s.now()
+	// Aligned to the hour so every hour is averaged from all its readings
+	rawCutoff := now.Add(-s.raw).Truncate(time.Hour).Unix()
+	hourlyCutoff := now.Add(-s.hourly).Unix()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if _, err := tx.ExecContext(ctx, downsampleSQL, rawCutoff); err != nil {
+		return fmt.Errorf("downsampling history: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, "DELETE FROM measures WHERE timestamp < ?", rawCutoff); err != nil {
+		return fmt.Errorf("pruning history: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, "DELETE FROM measures_hourly WHERE timestamp < ?", hourlyCutoff); err != nil {
+		return fmt.Errorf("pruning hourly history: %w", err)
+	}
+	return tx.Commit()
+}
+
+// Query selects the stored measures of a location.
+type Query struct {
+	// LocationID is the location, 0 for a monitor read through its local
+	// API.
+	LocationID int
+	// From and To bound the timestamps, From included and To excluded.
+	From, To time.Time
+	// Hourly averages the readings per hour. Otherwise the readings are
+	// returned as stored: raw for the retention period, hourly before.
+	Hourly bool
+}
+
+// Query returns the measures matching q ordered by timestamp. Hourly
+// averages are stamped with the start of their hour.
+func (s *Store) Query(ctx context.Context, q Query) ([]airgradient.Measures, error) {
+	query := selectSQL
+	if q.Hourly {
+		query = selectHourlySQL
+	}
+	rows, err := s.db.QueryContext(ctx, query, q.LocationID, q.From.Unix(), q.To.Unix(), q.LocationID, q.From.Unix(), q.To.Unix())
+	if err != nil {
+		return nil, fmt.Errorf("querying history: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var measures []airgradient.Measures
+	for rows.Next() {
+		m, err := scanRow(rows)
+		if err != nil {
+			return nil, fmt.Errorf("reading history: %w", err)
+		}
+		measures = append(measures, m)
+	}
+	return measures, rows.Err()
+}
+
+// migrations upgrade the schema one version at a time. The schema version
+// is kept in SQLite's user_version. Append to the list, never edit an entry.
+var migrations = [][]string{
+	{
+		createTableSQL("measures", ""),
+		createTableSQL("measures_hourly", "samples INTEGER NOT NULL, "),
+	},
+}
+
+// migrate applies the migrations the database has not seen yet.
+func migrate(ctx context.Context, db *sql.DB) error {
+	var version int
+	if err := db.QueryRowContext(ctx, "PRAGMA user_version").Scan(&version); err != nil {
+		return err
+	}
+	if version > len(migrations) {
+		return fmt.Errorf("schema version %d is newer than this AirDash supports (%d)", version, len(migrations))
+	}
+
+	for i := version; i < len(migrations); i++ {
+		tx, err := db.BeginTx(ctx, nil)
+		if err != nil {
+			return err
+		}
+		for _, stmt := range migrations[i] {
+			if _, err := tx.ExecContext(ctx, stmt); err != nil {
+				_ = tx.Rollback()
+				return fmt.Errorf("schema version %d: %w", i+1, err)
+			}
+		}
+		// PRAGMA does not take parameters
+		if _, err := tx.ExecContext(ctx, fmt.Sprintf("PRAGMA user_version = %d", i+1)); err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func createTableSQL(table, extra string) string {
+	columns := make([]string, 0, len(textColumns)+len(numberColumns)+len(valueColumns))
+	for _, c := range textColumns {
+		columns = append(columns, c.name+" TEXT NOT NULL DEFAULT ''")
+	}
+	for _, c := range numberColumns {
+		columns = append(columns, c.name+" REAL NOT NULL DEFAULT 0")
+	}
+	for _, c := range valueColumns {
+		columns = append(columns, c.name+" REAL")
+	}
+	return fmt.Sprintf("CREATE TABLE %s (location_id INTEGER NOT NULL, timestamp INTEGER NOT NULL, %s%s, PRIMARY KEY (location_id, timestamp)) WITHOUT ROWID",
+		table, extra, strings.Join(columns, ", "))
+}
@@ -0,0 +1,169 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ljagiello/airdash/airgradient"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var start = time.Date(2023, 10, 10, 3, 0, 0, 0, time.UTC)
+
+func reading(minutes int, pm02 float64) airgradient.Measures {
+	return airgradient.Measures{
+		LocationID:         12345,
+		LocationName:       "Test Loc",
+		Serialno:           "aabb12",
+		FirmwareVersion:    "3.1.1",
+		LedMode:            "co2",
+		LedCo2ThresholdEnd: 4000,
+		Pm02:               airgradient.NewValue(pm02),
+		Rco2:               airgradient.NewValue(548),
+		Timestamp:          start.Add(time.Duration(minutes) * time.Minute),
+	}
+}
+
+func openTestStore(t *testing.T, now *time.Time) *Store {
+	t.Helper()
+	s, err := Open(Config{Path: filepath.Join(t.TempDir(), "airdash", "history.db")}, WithClock(func() time.Time { return *now }))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = s.Close() })
+	return s
+}
+
+func TestInsertAndQuery(t *testing.T) {
+	now := start.Add(time.Hour)
+	s := openTestStore(t, &now)
+	ctx := context.Background()
+
+	corrected := reading(1, 3.2)
+	corrected.Pm02Raw = airgradient.NewValue(4)
+	corrected.Pm02Correction = airgradient.CorrectionEPA
+	other := reading(1, 9)
+	other.LocationID = 23456
+
+	inserted, err := s.Insert(ctx, []airgradient.Measures{reading(0, 4), corrected, other, {LocationID: 12345}})
+	require.NoError(t, err)
+	assert.Equal(t, 3, inserted)
+
+	// The same reading is only stored once
+	inserted, err = s.Insert(ctx, []airgradient.Measures{reading(0, 4)})
+	require.NoError(t, err)
+	assert.Equal(t, 0, inserted)
+
+	measures, err := s.Query(ctx, Query{LocationID: 12345, From: start, To: start.Add(time.Hour)})
+	require.NoError(t, err)
+	require.Len(t, measures, 2)
+	assert.Equal(t, reading(0, 4), measures[0])
+	assert.Equal(t, corrected, measures[1])
+	assert.False(t, measures[0].Pm01.Valid, "missing readings stay missing")
+
+	// To is excluded
+	measures, err = s.Query(ctx, Query{LocationID: 12345, From: start, To: start.Add(time.Minute)})
+	require.NoError(t, err)
+	assert.Len(t, measures, 1)
+}
+
+func TestQueryHourly(t *testing.T) {
+	now := start.Add(3 * time.Hour)
+	s := openTestStore(t, &now)
+	ctx := context.Background()
+
+	missing := reading(70, 0)
+	missing.Pm02 = airgradient.Value{}
+	_, err := s.Insert(ctx, []airgradient.Measures{reading(0, 4), reading(30, 8), reading(60, 10), missing})
+	require.NoError(t, err)
+
+	measures, err := s.Query(ctx, Query{LocationID: 12345, From: start, To: now, Hourly: true})
+	require.NoError(t, err)
+	require.Len(t, measures, 2)
+	assert.Equal(t, start, measures[0].Timestamp)
+	assert.InDelta(t, 6, measures[0].Pm02.Float64, 1e-9)
+	assert.Equal(t, start.Add(time.Hour), measures[1].Timestamp)
+	assert.InDelta(t, 10, measures[1].Pm02.Float64, 1e-9, "missing readings are left out of the average")
+	assert.Equal(t, "Test Loc", measures[1].LocationName)
+}
+
+func TestCompact(t *testing.T) {
+	now := start.Add(30 * time.Minute)
+	s := openTestStore(t, &now)
+	ctx := context.Background()
+
+	_, err := s.Insert(ctx, []airgradient.Measures{reading(0, 4), reading(30, 8), reading(65, 10)})
+	require.NoError(t, err)
+
+	// A week later the first hour is averaged, the second hour is not
+	// complete before the cutoff yet
+	now = start.Add(DefaultRawRetention + 90*time.Minute)
+	require.NoError(t, s.Compact(ctx))
+
+	measures, err := s.Query(ctx, Query{LocationID: 12345, From: start, To: now})
+	require.NoError(t, err)
+	require.Len(t, measures, 2)
+	assert.Equal(t, start, measures[0].Timestamp)
+	assert.InDelta(t, 6, measures[0].Pm02.Float64, 1e-9)
+	assert.Equal(t, start.Add(65*time.Minute), measures[1].Timestamp)
+	assert.InDelta(t, 10, measures[1].Pm02.Float64, 1e-9)
+
+	// A late reading for an averaged hour is weighed in
+	_, err = s.Insert(ctx, []airgradient.Measures{reading(45, 12)})
+	require.NoError(t, err)
+	require.NoError(t, s.Compact(ctx))
+	measures, err = s.Query(ctx, Query{LocationID: 12345, From: start, To: start.Add(time.Hour)})
+	require.NoError(t, err)
+	require.Len(t, measures, 1)
+	assert.InDelta(t, 8, measures[0].Pm02.Float64, 1e-9)
+
+	// Hourly averages expire after a year
+	now = start.Add(DefaultHourlyRetention + 2*time.Hour)
+	require.NoError(t, s.Compact(ctx))
+	measures, err = s.Query(ctx, Query{LocationID: 12345, From: start, To: now})
+	require.NoError(t, err)
+	assert.Empty(t, measures)
+}
+
+func TestPublishCompactsHourly(t *testing.T) {
+	now := start
+	s := openTestStore(t, &now)
+	ctx := context.Background()
+
+	require.NoError(t, s.Publish(ctx, []airgradient.Measures{reading(0, 4)}))
+	now = start.Add(DefaultRawRetention + 2*time.Hour)
+	require.NoError(t, s.Publish(ctx, []airgradient.Measures{reading(1, 4)}))
+
+	var raw int
+	require.NoError(t, s.db.QueryRow("SELECT COUNT(*) FROM measures").Scan(&raw))
+	assert.Equal(t, 0, raw)
+	var hourly int
+	require.NoError(t, s.db.QueryRow("SELECT COUNT(*) FROM measures_hourly").Scan(&hourly))
+	assert.Equal(t, 1, hourly)
+}
+
+func TestMigrate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.db")
+	s, err := Open(Config{Path: path})
+	require.NoError(t, err)
+	require.NoError(t, s.Close())
+
+	// Reopening keeps the schema version and data
+	s, err = Open(Config{Path: path})
+	require.NoError(t, err)
+	var version int
+	require.NoError(t, s.db.QueryRow("PRAGMA user_version").Scan(&version))
+	assert.Equal(t, len(migrations), version)
+	require.NoError(t, s.Close())
+
+	// A database from a newer AirDash is refused
+	db, err := sql.Open("sqlite", path)
+	require.NoError(t, err)
+	_, err = db.Exec("PRAGMA user_version = 99")
+	require.NoError(t, err)
+	require.NoError(t, db.Close())
+	_, err = Open(Config{Path: path})
+	require.ErrorContains(t, err, "schema version 99 is newer")
+}